@@ -0,0 +1,32 @@
+package main
+
+import "testing"
+
+func TestUnifiedDiff(t *testing.T) {
+	a := "one\ntwo\nthree\nfour\nfive\nsix\nseven\neight\nnine\nten\neleven\ntwelve\n"
+	b := "one\ntwo\nTHREE\nfour\nfive\nsix\nseven\neight\nnine\nten\neleven\ntwelve\nthirteen\n"
+
+	want := `--- old
++++ new
+@@ -1,6 +1,6 @@
+ one
+ two
+-three
++THREE
+ four
+ five
+ six
+@@ -10,3 +10,4 @@
+ ten
+ eleven
+ twelve
++thirteen
+`
+	assertEqual(t, "diff", unifiedDiff("old", "new", a, b), want)
+	assertEqual(t, "equal inputs", unifiedDiff("old", "new", a, a), "")
+}
+
+func TestUnifiedDiffFromEmpty(t *testing.T) {
+	want := "--- old\n+++ new\n@@ -0,0 +1,2 @@\n+a\n+b\n"
+	assertEqual(t, "diff", unifiedDiff("old", "new", "", "a\nb\n"), want)
+}
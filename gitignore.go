@@ -0,0 +1,89 @@
+package main
+
+import (
+	"bufio"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/sammcj/jmake/internal/glob"
+)
+
+// ignoreRule is a single pattern from a .gitignore file.
+type ignoreRule struct {
+	pattern  string
+	negate   bool // "!pattern" re-includes a previously ignored path
+	dirOnly  bool // "pattern/" matches directories only
+	anchored bool // pattern contains a slash, so matches from the root
+}
+
+// gitignore is a minimal .gitignore matcher covering the common syntax:
+// comments, negation, directory-only patterns, anchoring and "**".
+// Only the root .gitignore and .git/info/exclude are read.
+type gitignore struct {
+	rules []ignoreRule
+}
+
+// loadGitignore reads the ignore rules for the repository rooted at dir.
+// Missing files are not an error; they simply contribute no rules.
+func loadGitignore(dir string) *gitignore {
+	g := &gitignore{}
+	for _, name := range []string{".git/info/exclude", ".gitignore"} {
+		f, err := os.Open(filepath.Join(dir, name))
+		if err != nil {
+			continue
+		}
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			g.add(scanner.Text())
+		}
+		f.Close()
+	}
+	return g
+}
+
+// add parses one line of a .gitignore file.
+func (g *gitignore) add(line string) {
+	line = strings.TrimRight(line, " \t")
+	if line == "" || strings.HasPrefix(line, "#") {
+		return
+	}
+
+	var r ignoreRule
+	if after, ok := strings.CutPrefix(line, "!"); ok {
+		r.negate = true
+		line = after
+	}
+	if after, ok := strings.CutSuffix(line, "/"); ok {
+		r.dirOnly = true
+		line = after
+	}
+	if strings.Contains(line, "/") {
+		r.anchored = true
+		line = strings.TrimPrefix(line, "/")
+	}
+	r.pattern = line
+	g.rules = append(g.rules, r)
+}
+
+// ignored reports whether the slash-separated path rel, relative to the
+// repository root, is ignored. As in git, the last matching rule wins.
+func (g *gitignore) ignored(rel string, isDir bool) bool {
+	ignored := false
+	for _, r := range g.rules {
+		if r.dirOnly && !isDir {
+			continue
+		}
+		var match bool
+		if r.anchored {
+			match = glob.Match(r.pattern, rel)
+		} else {
+			match = glob.Match(r.pattern, path.Base(rel))
+		}
+		if match {
+			ignored = !r.negate
+		}
+	}
+	return ignored
+}
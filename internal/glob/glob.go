@@ -0,0 +1,107 @@
+// Package glob matches slash-separated paths against the glob patterns of
+// [inputs] and [outputs] attributes and --watch.
+package glob
+
+import (
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Match reports whether the slash-separated name matches pattern.
+// Patterns use path.Match syntax per segment, plus "**" which matches
+// zero or more whole segments.
+func Match(pattern, name string) bool {
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(name, "/"))
+}
+
+func matchSegments(pat, name []string) bool {
+	for len(pat) > 0 {
+		if pat[0] == "**" {
+			rest := pat[1:]
+			for i := 0; i <= len(name); i++ {
+				if matchSegments(rest, name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(name) == 0 {
+			return false
+		}
+		if ok, err := path.Match(pat[0], name[0]); err != nil || !ok {
+			return false
+		}
+		pat, name = pat[1:], name[1:]
+	}
+	return len(name) == 0
+}
+
+// Base returns the leading directory of pattern that contains no glob
+// metacharacters, or "." if the first segment is already a pattern.
+func Base(pattern string) string {
+	var base []string
+	for _, seg := range strings.Split(pattern, "/") {
+		if strings.ContainsAny(seg, "*?[") {
+			break
+		}
+		base = append(base, seg)
+	}
+	// The final literal segment may be a file rather than a directory.
+	if len(base) == len(strings.Split(pattern, "/")) {
+		base = base[:len(base)-1]
+	}
+	if len(base) == 0 {
+		return "."
+	}
+	return strings.Join(base, "/")
+}
+
+// Expand returns the regular files under dir matching any of the
+// patterns, as sorted slash-separated paths relative to dir.
+func Expand(dir string, patterns []string) ([]string, error) {
+	seen := make(map[string]bool)
+	for _, pat := range patterns {
+		pat = path.Clean(filepath.ToSlash(pat))
+		if !strings.ContainsAny(pat, "*?[") {
+			if fi, err := os.Stat(filepath.Join(dir, pat)); err == nil && fi.Mode().IsRegular() {
+				seen[pat] = true
+			}
+			continue
+		}
+		root := filepath.Join(dir, filepath.FromSlash(Base(pat)))
+		err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				if p == root {
+					return fs.SkipDir // missing base directory matches nothing
+				}
+				return err
+			}
+			if d.IsDir() {
+				return nil
+			}
+			rel, err := filepath.Rel(dir, p)
+			if err != nil {
+				return err
+			}
+			rel = filepath.ToSlash(rel)
+			if Match(pat, rel) {
+				seen[rel] = true
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	files := make([]string, 0, len(seen))
+	for f := range seen {
+		files = append(files, f)
+	}
+	sort.Strings(files)
+	return files, nil
+}
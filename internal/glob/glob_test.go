@@ -0,0 +1,52 @@
+package glob
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		pattern string
+		name    string
+		want    bool
+	}{
+		{"*.go", "main.go", true},
+		{"*.go", "sub/main.go", false},
+		{"src/**/*.go", "src/a.go", true},
+		{"src/**/*.go", "src/x/y/a.go", true},
+		{"src/**/*.go", "lib/a.go", false},
+		{"**", "any/thing", true},
+		{"bin/app", "bin/app", true},
+	}
+
+	for _, tt := range tests {
+		got := Match(tt.pattern, tt.name)
+		if got != tt.want {
+			t.Errorf("Match(%q, %q) = %v, want %v", tt.pattern, tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestExpand(t *testing.T) {
+	dir := t.TempDir()
+	for _, f := range []string{"go.mod", "src/a.go", "src/x/b.go", "src/x/notes.txt"} {
+		p := filepath.Join(dir, f)
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(f), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	files, err := Expand(dir, []string{"src/**/*.go", "go.mod", "missing/*.go"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got, want := strings.Join(files, " "), "go.mod src/a.go src/x/b.go"; got != want {
+		t.Errorf("files: got %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,671 @@
+package justfile
+
+import (
+	"fmt"
+	"strings"
+)
+
+// NodeKind identifies the kind of a Node.
+type NodeKind int
+
+const (
+	TokenNode         NodeKind = iota // a leaf holding a single token
+	FileNode                          // the whole justfile
+	BlankNode                         // an empty or whitespace-only line
+	CommentNode                       // a comment on a line of its own
+	AttributeNode                     // [name], [name("arg"), other]
+	AliasNode                         // alias name := target
+	AssignmentNode                    // name := value, or export name := value
+	SettingNode                       // set name := value
+	ImportNode                        // import "path"
+	ModuleNode                        // mod name "path"
+	RecipeNode                        // a recipe header and its body lines
+	ParameterNode                     // a recipe parameter, with its default
+	DependencyNode                    // a recipe dependency, with its arguments
+	BodyLineNode                      // one line of a recipe body
+	InterpolationNode                 // {{expression}} in a body line
+	ExpressionNode                    // a value, or values joined by operators
+)
+
+var nodeKindNames = map[NodeKind]string{
+	TokenNode: "token", FileNode: "file", BlankNode: "blank", CommentNode: "comment",
+	AttributeNode: "attribute", AliasNode: "alias", AssignmentNode: "assignment",
+	SettingNode: "setting", ImportNode: "import", ModuleNode: "module",
+	RecipeNode: "recipe", ParameterNode: "parameter", DependencyNode: "dependency",
+	BodyLineNode: "body line", InterpolationNode: "interpolation", ExpressionNode: "expression",
+}
+
+func (k NodeKind) String() string {
+	if s, ok := nodeKindNames[k]; ok {
+		return s
+	}
+	return fmt.Sprintf("NodeKind(%d)", int(k))
+}
+
+// Node is a node of a justfile's concrete syntax tree. Leaves are
+// TokenNodes; every other node holds the nodes it spans, in source order.
+// Nothing is dropped, trivia included, so the tree prints back to exactly
+// the source it was parsed from.
+type Node struct {
+	Kind     NodeKind
+	Token    Token // for TokenNode
+	Children []*Node
+}
+
+// Tokens returns the tokens under n in source order.
+func (n *Node) Tokens() []Token {
+	if n.Kind == TokenNode {
+		return []Token{n.Token}
+	}
+	var toks []Token
+	for _, c := range n.Children {
+		toks = append(toks, c.Tokens()...)
+	}
+	return toks
+}
+
+// Start returns the byte offset of the start of n.
+func (n *Node) Start() int {
+	if n.Kind == TokenNode {
+		return n.Token.Offset
+	}
+	if len(n.Children) == 0 {
+		return 0
+	}
+	return n.Children[0].Start()
+}
+
+// End returns the byte offset just past the end of n.
+func (n *Node) End() int {
+	if n.Kind == TokenNode {
+		return n.Token.End()
+	}
+	if len(n.Children) == 0 {
+		return 0
+	}
+	return n.Children[len(n.Children)-1].End()
+}
+
+// String returns the source text n was parsed from.
+func (n *Node) String() string {
+	var b strings.Builder
+	for _, t := range n.Tokens() {
+		b.WriteString(t.Text)
+	}
+	return b.String()
+}
+
+// ParseCST parses src into a lossless concrete syntax tree rooted at a
+// FileNode. Each top-level item, comment and blank line is a child of the
+// root, and a recipe's body lines are children of its RecipeNode.
+//
+// The parser is recursive descent over the tokens from Lex, following
+// just's grammar. Trivia is attached to the innermost node open when it is
+// reached.
+func ParseCST(src []byte) (*Node, error) {
+	toks, err := Lex(src)
+	if err != nil {
+		return nil, err
+	}
+	root := &Node{Kind: FileNode}
+	p := &parser{src: src, toks: toks, nodes: []*Node{root}}
+	for p.at().Kind != EOF {
+		if err := p.item(); err != nil {
+			return nil, err
+		}
+	}
+	p.take()
+	return root, nil
+}
+
+type parser struct {
+	src   []byte
+	toks  []Token
+	pos   int
+	nodes []*Node // open nodes; taken tokens are added to the last
+	depth int     // open delimiters, inside which newlines are trivia
+}
+
+// at returns the current token.
+func (p *parser) at() Token {
+	return p.toks[p.pos]
+}
+
+func (p *parser) trivia(t Token) bool {
+	return t.trivia() || (t.Kind == Newline && p.depth > 0)
+}
+
+// take adds the current token to the innermost open node and moves past it.
+func (p *parser) take() Token {
+	t := p.toks[p.pos]
+	top := p.nodes[len(p.nodes)-1]
+	top.Children = append(top.Children, &Node{Kind: TokenNode, Token: t})
+	switch t.Kind {
+	case ParenL, BracketL, BraceL:
+		p.depth++
+	case ParenR, BracketR, BraceR:
+		p.depth--
+	}
+	if t.Kind != EOF {
+		p.pos++
+	}
+	return t
+}
+
+// skip takes any trivia at the current position.
+func (p *parser) skip() {
+	for p.trivia(p.at()) {
+		p.take()
+	}
+}
+
+// peek returns the nth meaningful token from the current position,
+// counting from 0, without taking anything.
+func (p *parser) peek(n int) Token {
+	i := p.pos
+	for ; ; i++ {
+		if p.trivia(p.toks[i]) {
+			continue
+		}
+		if n == 0 || p.toks[i].Kind == EOF {
+			return p.toks[i]
+		}
+		n--
+	}
+}
+
+// is reports whether the next meaningful token has the kind, and the text
+// if text is not empty.
+func (p *parser) is(kind TokenKind, text string) bool {
+	t := p.peek(0)
+	return t.Kind == kind && (text == "" || t.Text == text)
+}
+
+// open starts a node of the kind as a child of the innermost open node.
+// Trivia before it stays with the parent.
+func (p *parser) open(kind NodeKind) {
+	p.skip()
+	p.start(kind)
+}
+
+// start starts a node of the kind at the current position, trivia included.
+func (p *parser) start(kind NodeKind) {
+	n := &Node{Kind: kind}
+	top := p.nodes[len(p.nodes)-1]
+	top.Children = append(top.Children, n)
+	p.nodes = append(p.nodes, n)
+}
+
+func (p *parser) close() {
+	p.nodes = p.nodes[:len(p.nodes)-1]
+}
+
+func (p *parser) errorf(t Token, format string, args ...any) error {
+	return fmt.Errorf("line %d: %s", lineOf(p.src, t.Offset), fmt.Sprintf(format, args...))
+}
+
+// unexpected returns an error for the next meaningful token, which is not
+// what was wanted.
+func (p *parser) unexpected(want string) error {
+	t := p.peek(0)
+	found := t.Kind.String()
+	if t.Kind == Name || t.Kind == String {
+		found = fmt.Sprintf("%s %s", t.Kind, t.Text)
+	}
+	return p.errorf(t, "expected %s, found %s", want, found)
+}
+
+// expect takes the next meaningful token, which must have the kind.
+func (p *parser) expect(kind TokenKind) (Token, error) {
+	if !p.is(kind, "") {
+		return Token{}, p.unexpected(kind.String())
+	}
+	p.skip()
+	return p.take(), nil
+}
+
+// keyword takes the next meaningful token, which must be the name word.
+func (p *parser) keyword(word string) error {
+	if !p.is(Name, word) {
+		return p.unexpected("'" + word + "'")
+	}
+	p.skip()
+	p.take()
+	return nil
+}
+
+// eol takes the newline that ends an item, and any comment before it.
+func (p *parser) eol() error {
+	switch t := p.peek(0); t.Kind {
+	case Newline:
+		p.skip()
+		p.take()
+		return nil
+	case EOF:
+		p.skip()
+		return nil
+	}
+	return p.unexpected("end of line")
+}
+
+// item parses a top-level item, comment or blank line.
+func (p *parser) item() error {
+	first, second := p.peek(0), p.peek(1)
+	switch {
+	case first.Kind == Newline || first.Kind == EOF:
+		kind := BlankNode
+		for i := p.pos; p.toks[i].Kind != first.Kind; i++ {
+			if p.toks[i].Kind == Comment {
+				kind = CommentNode
+			}
+		}
+		p.start(kind)
+		defer p.close()
+		return p.eol()
+	case first.Kind == Indent:
+		return p.errorf(first, "indented line outside a recipe")
+	case first.Kind == BracketL:
+		return p.attribute()
+	case first.Kind != Name:
+		if first.Kind == At {
+			return p.recipe()
+		}
+		return p.unexpected("recipe, assignment or setting")
+	case second.Kind == ColonEquals:
+		return p.assignment()
+	case second.Kind == Name && first.Text == "export":
+		return p.assignment()
+	case second.Kind == Name && first.Text == "alias":
+		return p.alias()
+	case second.Kind == Name && first.Text == "set":
+		return p.setting()
+	case first.Text == "import" && (second.Kind == String || second.Kind == QuestionMark):
+		return p.importItem()
+	case first.Text == "mod" && (second.Kind == Name || second.Kind == QuestionMark):
+		return p.module()
+	}
+	return p.recipe()
+}
+
+// alias : 'alias' NAME ':=' NAME eol
+func (p *parser) alias() error {
+	p.open(AliasNode)
+	defer p.close()
+	if err := p.keyword("alias"); err != nil {
+		return err
+	}
+	for _, kind := range []TokenKind{Name, ColonEquals, Name} {
+		if _, err := p.expect(kind); err != nil {
+			return err
+		}
+	}
+	return p.eol()
+}
+
+// assignment : 'export'? NAME ':=' expression eol
+func (p *parser) assignment() error {
+	p.open(AssignmentNode)
+	defer p.close()
+	if p.peek(1).Kind != ColonEquals {
+		if err := p.keyword("export"); err != nil {
+			return err
+		}
+	}
+	if _, err := p.expect(Name); err != nil {
+		return err
+	}
+	if _, err := p.expect(ColonEquals); err != nil {
+		return err
+	}
+	if err := p.expression(); err != nil {
+		return err
+	}
+	return p.eol()
+}
+
+// setting : 'set' NAME (':=' (expression | '[' string (',' string)* ','? ']'))? eol
+func (p *parser) setting() error {
+	p.open(SettingNode)
+	defer p.close()
+	if err := p.keyword("set"); err != nil {
+		return err
+	}
+	if _, err := p.expect(Name); err != nil {
+		return err
+	}
+	if p.is(ColonEquals, "") {
+		p.skip()
+		p.take()
+		if p.is(BracketL, "") {
+			if err := p.list(BracketL, BracketR, func() error {
+				_, err := p.expect(String)
+				return err
+			}); err != nil {
+				return err
+			}
+		} else if err := p.expression(); err != nil {
+			return err
+		}
+	}
+	return p.eol()
+}
+
+// import : 'import' '?'? string eol
+func (p *parser) importItem() error {
+	p.open(ImportNode)
+	defer p.close()
+	if err := p.keyword("import"); err != nil {
+		return err
+	}
+	if p.is(QuestionMark, "") {
+		p.skip()
+		p.take()
+	}
+	if _, err := p.expect(String); err != nil {
+		return err
+	}
+	return p.eol()
+}
+
+// module : 'mod' '?'? NAME string? eol
+func (p *parser) module() error {
+	p.open(ModuleNode)
+	defer p.close()
+	if err := p.keyword("mod"); err != nil {
+		return err
+	}
+	if p.is(QuestionMark, "") {
+		p.skip()
+		p.take()
+	}
+	if _, err := p.expect(Name); err != nil {
+		return err
+	}
+	if p.is(String, "") {
+		p.skip()
+		p.take()
+	}
+	return p.eol()
+}
+
+// attributes : '[' attribute (',' attribute)* ']' eol
+// attribute  : NAME ('(' string (',' string)* ')' | ':' string)?
+func (p *parser) attribute() error {
+	p.open(AttributeNode)
+	defer p.close()
+	err := p.list(BracketL, BracketR, func() error {
+		if _, err := p.expect(Name); err != nil {
+			return err
+		}
+		switch {
+		case p.is(ParenL, ""):
+			return p.list(ParenL, ParenR, func() error {
+				_, err := p.expect(String)
+				return err
+			})
+		case p.is(Colon, ""):
+			p.skip()
+			p.take()
+			_, err := p.expect(String)
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return p.eol()
+}
+
+// list parses elements separated by commas between the open and close
+// delimiters, allowing a trailing comma.
+func (p *parser) list(open, close TokenKind, element func() error) error {
+	if _, err := p.expect(open); err != nil {
+		return err
+	}
+	for !p.is(close, "") {
+		if err := element(); err != nil {
+			return err
+		}
+		if !p.is(Comma, "") {
+			break
+		}
+		p.skip()
+		p.take()
+	}
+	_, err := p.expect(close)
+	return err
+}
+
+// recipe : '@'? NAME parameter* ':' dependency* ('&&' dependency*)? eol body?
+func (p *parser) recipe() error {
+	p.open(RecipeNode)
+	defer p.close()
+	if p.is(At, "") {
+		p.skip()
+		p.take()
+	}
+	if _, err := p.expect(Name); err != nil {
+		return err
+	}
+	for p.is(Name, "") || p.is(Dollar, "") || p.is(Asterisk, "") || p.is(Plus, "") {
+		if err := p.parameter(); err != nil {
+			return err
+		}
+	}
+	if _, err := p.expect(Colon); err != nil {
+		return err
+	}
+	for p.is(Name, "") || p.is(ParenL, "") || p.is(AmpersandAmpersand, "") {
+		if p.is(AmpersandAmpersand, "") {
+			p.skip()
+			p.take()
+			continue
+		}
+		if err := p.dependency(); err != nil {
+			return err
+		}
+	}
+	if err := p.eol(); err != nil {
+		return err
+	}
+	return p.body()
+}
+
+// parameter : ('*' | '+')? '$'? NAME ('=' value)?
+func (p *parser) parameter() error {
+	p.open(ParameterNode)
+	defer p.close()
+	if p.is(Asterisk, "") || p.is(Plus, "") {
+		p.take()
+	}
+	if p.is(Dollar, "") {
+		p.skip()
+		p.take()
+	}
+	if _, err := p.expect(Name); err != nil {
+		return err
+	}
+	if p.is(Equals, "") {
+		p.skip()
+		p.take()
+		p.open(ExpressionNode)
+		defer p.close()
+		return p.value()
+	}
+	return nil
+}
+
+// dependency : NAME | '(' NAME expression* ')'
+func (p *parser) dependency() error {
+	p.open(DependencyNode)
+	defer p.close()
+	if !p.is(ParenL, "") {
+		_, err := p.expect(Name)
+		return err
+	}
+	p.take()
+	if _, err := p.expect(Name); err != nil {
+		return err
+	}
+	for !p.is(ParenR, "") {
+		if err := p.expression(); err != nil {
+			return err
+		}
+	}
+	_, err := p.expect(ParenR)
+	return err
+}
+
+// body : (blank* INDENT line)*
+//
+// Blank lines belong to the body when another body line follows them.
+func (p *parser) body() error {
+	for {
+		i := p.pos
+		for p.toks[i].Kind == Whitespace || p.toks[i].Kind == Newline {
+			i++
+		}
+		if p.toks[i].Kind != Indent {
+			return nil
+		}
+		for p.pos < i {
+			p.start(BlankNode)
+			for p.at().Kind != Newline {
+				p.take()
+			}
+			p.take()
+			p.close()
+		}
+		if err := p.bodyLine(); err != nil {
+			return err
+		}
+	}
+}
+
+// line : INDENT (TEXT | '{{' expression '}}')* NEWLINE
+func (p *parser) bodyLine() error {
+	p.open(BodyLineNode)
+	defer p.close()
+	p.take()
+	for {
+		switch p.at().Kind {
+		case Text:
+			p.take()
+		case InterpolationStart:
+			p.open(InterpolationNode)
+			p.take()
+			if err := p.expression(); err != nil {
+				return err
+			}
+			if _, err := p.expect(InterpolationEnd); err != nil {
+				return err
+			}
+			p.close()
+		case Newline:
+			p.take()
+			return nil
+		default:
+			return nil
+		}
+	}
+}
+
+// expression parses an expression into an ExpressionNode.
+func (p *parser) expression() error {
+	p.open(ExpressionNode)
+	defer p.close()
+	return p.expr()
+}
+
+// expression : 'if' condition '{' expression '}' 'else' ('{' expression '}' | expression)
+//
+//	| '/'? value (('+' | '/') value)*
+//
+// condition  : expression ('==' | '!=' | '=~') expression
+func (p *parser) expr() error {
+	if p.is(Name, "if") && p.peek(1).Kind != ParenL {
+		p.skip()
+		p.take()
+		if err := p.expr(); err != nil {
+			return err
+		}
+		if !p.is(EqualsEquals, "") && !p.is(BangEquals, "") && !p.is(EqualsTilde, "") {
+			return p.unexpected("'==', '!=' or '=~'")
+		}
+		p.skip()
+		p.take()
+		if err := p.expr(); err != nil {
+			return err
+		}
+		if err := p.block(); err != nil {
+			return err
+		}
+		if err := p.keyword("else"); err != nil {
+			return err
+		}
+		if p.is(Name, "if") {
+			return p.expr()
+		}
+		return p.block()
+	}
+
+	if p.is(Slash, "") {
+		p.skip()
+		p.take()
+	}
+	if err := p.value(); err != nil {
+		return err
+	}
+	for p.is(Plus, "") || p.is(Slash, "") {
+		p.skip()
+		p.take()
+		if err := p.value(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// block : '{' expression '}'
+func (p *parser) block() error {
+	if _, err := p.expect(BraceL); err != nil {
+		return err
+	}
+	if err := p.expr(); err != nil {
+		return err
+	}
+	_, err := p.expect(BraceR)
+	return err
+}
+
+// value : NAME '(' sequence? ')' | NAME | string | backtick | '(' expression ')'
+func (p *parser) value() error {
+	switch p.peek(0).Kind {
+	case String, Backtick:
+		p.skip()
+		p.take()
+		return nil
+	case Name:
+		p.skip()
+		p.take()
+		if p.at().Kind == ParenL {
+			return p.list(ParenL, ParenR, p.expr)
+		}
+		return nil
+	case ParenL:
+		p.skip()
+		p.take()
+		if err := p.expr(); err != nil {
+			return err
+		}
+		_, err := p.expect(ParenR)
+		return err
+	}
+	return p.unexpected("expression")
+}
+
+// lineOf returns the 1-based line number of the byte at offset.
+func lineOf(src []byte, offset int) int {
+	return 1 + strings.Count(string(src[:offset]), "\n")
+}
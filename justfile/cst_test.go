@@ -0,0 +1,127 @@
+package justfile
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCSTRoundTrip(t *testing.T) {
+	inputs := map[string]string{
+		"full": `# --- Settings ---
+set shell := ["bash", "-cu"]
+
+version := "1.0"  # trailing comment
+export GREETING := 'say "hi"'
+now := ` + "`date`" + `
+long := "a" + \
+    "b"
+list := join(
+  "x",
+  "y",
+)
+text := """
+    indented
+"""
+
+alias b := build
+
+# Build it
+# with all the flags
+[group("dev"), inputs("src/*.go")]
+@build target="debug" *flags: gen
+    go build {{flags}} -o {{ target / "out" }}
+
+    echo '{{{{literal}}'
+    # a shell comment, not a doc comment
+gen:
+	go generate
+`,
+		"crlf":          "a:\r\n\techo a\r\n\r\nb: a\r\n",
+		"no final line": "a:\n\techo a",
+		"empty":         "",
+		"blank only":    "\n  \n\t\n",
+	}
+	for name, input := range inputs {
+		root, err := ParseCST([]byte(input))
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", name, err)
+		}
+		assertEqual(t, name, root.String(), input)
+
+		// Tokens are contiguous and each one's offset locates its text.
+		offset := 0
+		for _, tok := range root.Tokens() {
+			assertEqual(t, name+" offset", tok.Offset, offset)
+			assertEqual(t, name+" text", input[tok.Offset:tok.End()], tok.Text)
+			offset = tok.End()
+		}
+		assertEqual(t, name+" end", root.End(), len(input))
+	}
+}
+
+func TestCSTItems(t *testing.T) {
+	input := `# --- Section ---
+x := "a:b"
+export y := ("c" +
+  "d")
+
+alias b := build
+
+# doc
+[private]
+build arg="hello world": x
+    echo 1
+
+    echo 2
+
+set quiet
+`
+	root, err := ParseCST([]byte(input))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var kinds []string
+	for _, n := range root.Children {
+		kinds = append(kinds, n.Kind.String())
+	}
+	assertEqual(t, "kinds", strings.Join(kinds, ","),
+		"comment,assignment,assignment,blank,alias,blank,comment,attribute,recipe,blank,setting,token")
+
+	recipe := root.Children[8]
+	assertEqual(t, "recipe start", recipe.Start(), strings.Index(input, "build arg"))
+	assertEqual(t, "recipe end", recipe.End(), strings.Index(input, "echo 2\n")+len("echo 2\n"))
+
+	var body []string
+	for _, n := range recipe.Children {
+		if n.Kind == BodyLineNode || n.Kind == BlankNode {
+			body = append(body, n.Kind.String()+":"+strings.TrimSpace(n.String()))
+		}
+	}
+	assertEqual(t, "body", strings.Join(body, ","), "body line:echo 1,blank:,body line:echo 2")
+}
+
+func TestCSTErrors(t *testing.T) {
+	tests := []struct {
+		input, want string
+	}{
+		{"x := \"abc\n", "line 1: unterminated string"},
+		{"a:\n    echo\n\tx\n", "line 3: recipe line has inconsistent leading whitespace"},
+		{"x := (\"a\"\n", "line 2: unclosed '('"},
+		{"a:\n\techo {{x\n", "line 2: unterminated interpolation"},
+		{"  echo\n", "line 1: indented line outside a recipe"},
+		{"x := ]\n", "line 1: unexpected ']'"},
+		{"a b c\n", "line 1: expected ':', found newline"},
+		{"x := \"a\" +\n", "line 1: expected expression, found newline"},
+		{"[group(\"a\") b]\n", "line 1: expected ']', found name b"},
+		{"x := ^\n", "line 1: unexpected character '^'"},
+	}
+	for _, tt := range tests {
+		_, err := ParseCST([]byte(tt.input))
+		if err == nil {
+			t.Errorf("%q: expected error", tt.input)
+			continue
+		}
+		assertEqual(t, tt.input, err.Error(), tt.want)
+	}
+}
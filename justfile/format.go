@@ -0,0 +1,145 @@
+package justfile
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Format renders a Justfile as justfile source: variables first,
+// then aliases, then recipes separated by blank lines. Recipe bodies are
+// indented with four spaces.
+func Format(jf *Justfile) string {
+	var b strings.Builder
+
+	for _, v := range jf.Variables {
+		if v.Export {
+			b.WriteString("export ")
+		}
+		if v.Backtick {
+			fmt.Fprintf(&b, "%s := `%s`\n", v.Name, v.Value)
+		} else {
+			fmt.Fprintf(&b, "%s := %s\n", v.Name, justQuote(v.Value))
+		}
+	}
+	if len(jf.Variables) > 0 && (len(jf.Aliases) > 0 || len(jf.Recipes) > 0) {
+		b.WriteString("\n")
+	}
+
+	for _, a := range jf.Aliases {
+		fmt.Fprintf(&b, "alias %s := %s\n", a.Name, a.Target)
+	}
+	if len(jf.Aliases) > 0 && len(jf.Recipes) > 0 {
+		b.WriteString("\n")
+	}
+
+	for i := range jf.Recipes {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		b.WriteString(FormatRecipe(&jf.Recipes[i]))
+	}
+
+	return b.String()
+}
+
+// FormatComment returns text as # comment lines, each ending in a newline, as
+// written in justfiles, Makefiles and shell scripts. Empty text gives no
+// lines.
+func FormatComment(text string) string {
+	if text == "" {
+		return ""
+	}
+	var b strings.Builder
+	for line := range strings.SplitSeq(text, "\n") {
+		if line == "" {
+			b.WriteString("#\n")
+		} else {
+			b.WriteString("# " + line + "\n")
+		}
+	}
+	return b.String()
+}
+
+// formatAttribute renders a recipe attribute, e.g. [inputs("a", "b")].
+func formatAttribute(a Attribute) string {
+	if len(a.Args) == 0 {
+		return "[" + a.Name + "]"
+	}
+	args := make([]string, len(a.Args))
+	for i, arg := range a.Args {
+		args[i] = justQuote(arg)
+	}
+	return "[" + a.Name + "(" + strings.Join(args, ", ") + ")]"
+}
+
+// justQuote returns s as a justfile string literal. Double quotes are used
+// unless s contains characters that would need escaping in them, in which
+// case s is written as a raw single-quoted string if it can be.
+func justQuote(s string) string {
+	if !strings.ContainsAny(s, "\"\\\n\r\t") {
+		return `"` + s + `"`
+	}
+	if !strings.ContainsAny(s, "'\n\r\t") {
+		return "'" + s + "'"
+	}
+	return `"` + quoteEscaper.Replace(s) + `"`
+}
+
+// DefaultSource returns the parameter's default as justfile source: a
+// string literal, a variable name or a backtick.
+func (p Param) DefaultSource() string {
+	switch p.DefaultKind {
+	case DefaultVariable:
+		return p.Default
+	case DefaultBacktick:
+		return "`" + p.Default + "`"
+	}
+	return justQuote(p.Default)
+}
+
+var quoteEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\r", `\r`, "\t", `\t`)
+
+// FormatRecipe renders a single recipe as justfile source: its doc comment,
+// attributes, header and body.
+func FormatRecipe(r *Recipe) string {
+	var b strings.Builder
+
+	// A [doc] attribute stands in for the comment.
+	if !slices.ContainsFunc(r.Attributes, func(a Attribute) bool { return a.Name == "doc" }) {
+		b.WriteString(FormatComment(r.Doc))
+	}
+	for _, a := range r.Attributes {
+		b.WriteString(formatAttribute(a))
+		b.WriteString("\n")
+	}
+
+	if r.Silent {
+		b.WriteString("@")
+	}
+	b.WriteString(r.Name)
+	for _, p := range r.Params {
+		b.WriteString(" ")
+		b.WriteString(p.Variadic)
+		b.WriteString(p.Name)
+		if p.HasDefault {
+			b.WriteString("=")
+			b.WriteString(p.DefaultSource())
+		}
+	}
+	b.WriteString(":")
+	for _, d := range r.Dependencies {
+		b.WriteString(" ")
+		b.WriteString(d)
+	}
+	b.WriteString("\n")
+
+	for _, line := range r.Lines {
+		if line == "" {
+			b.WriteString("\n")
+			continue
+		}
+		fmt.Fprintf(&b, "    %s\n", line)
+	}
+	return b.String()
+}
@@ -0,0 +1,50 @@
+package justfile
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFormatRoundTrip(t *testing.T) {
+	input := `version := "1.0"
+export GREETING := 'say "hi"'
+now := ` + "`date`" + `
+
+alias b := build
+
+# Build it
+[inputs("src/*.go", "go.mod")]
+build target="debug" *flags: gen
+    go build {{flags}}
+
+gen:
+    go generate
+`
+
+	jf, err := Parse(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	assertEqual(t, "formatted", Format(jf), input)
+}
+
+func TestFormatQuotesCookedValues(t *testing.T) {
+	jf := &Justfile{Variables: []Variable{
+		{Name: "a", Value: "tab\there"},
+		{Name: "b", Value: `back\slash`},
+		{Name: "c", Value: "it's \"q\"\n"},
+	}}
+	formatted := Format(jf)
+	assertEqual(t, "formatted", formatted, `a := "tab\there"
+b := 'back\slash'
+c := "it's \"q\"\n"
+`)
+
+	back, err := Parse(strings.NewReader(formatted))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for i, v := range jf.Variables {
+		assertEqual(t, v.Name, back.Variables[i].Value, v.Value)
+	}
+}
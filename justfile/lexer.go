@@ -0,0 +1,348 @@
+package justfile
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// TokenKind identifies the kind of a Token.
+type TokenKind int
+
+const (
+	EOF        TokenKind = iota // end of input; always the last token, and empty
+	Whitespace                  // spaces and tabs within a line, or a \ line continuation
+	Newline                     // "\n" or "\r\n"
+	Comment                     // "#" up to the end of the line
+	Indent                      // the indentation of a recipe body line
+	Text                        // literal text in a recipe body line
+	Name                        // identifier, keyword such as alias or export, or bare number
+	String                      // '...', "...", '''...''' or """..."""
+	Backtick                    // `...` or ```...```
+
+	InterpolationStart // {{
+	InterpolationEnd   // }}
+
+	AmpersandAmpersand // &&
+	Asterisk           // *
+	At                 // @
+	BangEquals         // !=
+	BraceL             // {
+	BraceR             // }
+	BracketL           // [
+	BracketR           // ]
+	Colon              // :
+	ColonEquals        // :=
+	Comma              // ,
+	Dollar             // $
+	Equals             // =
+	EqualsEquals       // ==
+	EqualsTilde        // =~
+	ParenL             // (
+	ParenR             // )
+	Plus               // +
+	QuestionMark       // ?
+	Slash              // /
+)
+
+var tokenKindNames = map[TokenKind]string{
+	EOF: "end of file", Whitespace: "whitespace", Newline: "newline", Comment: "comment",
+	Indent: "indent", Text: "text", Name: "name", String: "string", Backtick: "backtick",
+	InterpolationStart: "'{{'", InterpolationEnd: "'}}'",
+	AmpersandAmpersand: "'&&'", Asterisk: "'*'", At: "'@'", BangEquals: "'!='",
+	BraceL: "'{'", BraceR: "'}'", BracketL: "'['", BracketR: "']'", Colon: "':'",
+	ColonEquals: "':='", Comma: "','", Dollar: "'$'", Equals: "'='", EqualsEquals: "'=='",
+	EqualsTilde: "'=~'", ParenL: "'('", ParenR: "')'", Plus: "'+'", QuestionMark: "'?'",
+	Slash: "'/'",
+}
+
+func (k TokenKind) String() string {
+	if s, ok := tokenKindNames[k]; ok {
+		return s
+	}
+	return fmt.Sprintf("TokenKind(%d)", int(k))
+}
+
+// Token is a lexical token of a justfile. Every byte of the input belongs
+// to exactly one token, so concatenating the Text of all tokens reproduces
+// the input.
+type Token struct {
+	Kind   TokenKind
+	Text   string
+	Offset int // byte offset of the token in the input
+}
+
+// End returns the byte offset just past the token.
+func (t Token) End() int {
+	return t.Offset + len(t.Text)
+}
+
+// trivia reports whether the token carries no meaning for the parser.
+func (t Token) trivia() bool {
+	return t.Kind == Whitespace || t.Kind == Comment
+}
+
+// operators are the punctuation tokens, longest first so that ":=" is
+// preferred over ":".
+var operators = []struct {
+	text string
+	kind TokenKind
+}{
+	{"&&", AmpersandAmpersand}, {"!=", BangEquals}, {":=", ColonEquals},
+	{"==", EqualsEquals}, {"=~", EqualsTilde},
+	{"*", Asterisk}, {"@", At}, {"{", BraceL}, {"}", BraceR}, {"[", BracketL},
+	{"]", BracketR}, {":", Colon}, {",", Comma}, {"$", Dollar}, {"=", Equals},
+	{"(", ParenL}, {")", ParenR}, {"+", Plus}, {"?", QuestionMark}, {"/", Slash},
+}
+
+// lexer splits a justfile into tokens. It works a line at a time: an
+// indented line is a recipe body line, lexed as text and interpolations,
+// and any other line is lexed as tokens up to the newline that ends it.
+// Newlines inside parentheses, brackets or braces, and escaped with a
+// trailing \, don't end a line.
+type lexer struct {
+	src    []byte
+	pos    int
+	tokens []Token
+
+	// open holds the delimiters opened and not yet closed: '(', '[', '{',
+	// or 'I' for an interpolation.
+	open []byte
+
+	// indent is the indentation of the current recipe body, "" outside one.
+	indent string
+}
+
+// Lex splits src into tokens, ending with an EOF token.
+func Lex(src []byte) ([]Token, error) {
+	l := &lexer{src: src}
+	for l.pos < len(l.src) {
+		if err := l.line(); err != nil {
+			return nil, err
+		}
+	}
+	if len(l.open) > 0 {
+		return nil, l.errorf("unclosed '%c'", l.open[len(l.open)-1])
+	}
+	l.emit(EOF, 0)
+	return l.tokens, nil
+}
+
+// emit appends a token for the next n bytes.
+func (l *lexer) emit(kind TokenKind, n int) {
+	l.tokens = append(l.tokens, Token{Kind: kind, Text: string(l.src[l.pos : l.pos+n]), Offset: l.pos})
+	l.pos += n
+}
+
+func (l *lexer) errorf(format string, args ...any) error {
+	return fmt.Errorf("line %d: %s", lineOf(l.src, l.pos), fmt.Sprintf(format, args...))
+}
+
+func (l *lexer) rest() []byte {
+	return l.src[l.pos:]
+}
+
+// newline returns the length of the newline at the current position, or 0.
+func (l *lexer) newline() int {
+	switch {
+	case bytes.HasPrefix(l.rest(), []byte("\n")):
+		return 1
+	case bytes.HasPrefix(l.rest(), []byte("\r\n")):
+		return 2
+	}
+	return 0
+}
+
+// blanks returns the length of the run of spaces and tabs at the current
+// position.
+func (l *lexer) blanks() int {
+	n := 0
+	for l.pos+n < len(l.src) && (l.src[l.pos+n] == ' ' || l.src[l.pos+n] == '\t') {
+		n++
+	}
+	return n
+}
+
+// line lexes a line, starting at its first byte.
+func (l *lexer) line() error {
+	ws := l.blanks()
+	l.pos += ws
+	blank := l.pos == len(l.src) || l.newline() > 0
+	l.pos -= ws
+
+	switch {
+	case blank:
+		// Blank lines neither start nor end a recipe body.
+		if ws > 0 {
+			l.emit(Whitespace, ws)
+		}
+		if n := l.newline(); n > 0 {
+			l.emit(Newline, n)
+		}
+		return nil
+	case ws > 0:
+		return l.bodyLine(ws)
+	default:
+		l.indent = ""
+		return l.tokensToEOL()
+	}
+}
+
+// bodyLine lexes an indented line as part of a recipe body. The body's
+// indentation is set by its first line; indentation beyond that is text.
+func (l *lexer) bodyLine(ws int) error {
+	if l.indent == "" {
+		l.indent = string(l.src[l.pos : l.pos+ws])
+	}
+	if !bytes.HasPrefix(l.rest(), []byte(l.indent)) {
+		return l.errorf("recipe line has inconsistent leading whitespace")
+	}
+	l.emit(Indent, len(l.indent))
+
+	for l.pos < len(l.src) {
+		if n := l.newline(); n > 0 {
+			l.emit(Newline, n)
+			return nil
+		}
+		if bytes.HasPrefix(l.rest(), []byte("{{")) && !bytes.HasPrefix(l.rest(), []byte("{{{{")) {
+			l.emit(InterpolationStart, 2)
+			l.open = append(l.open, 'I')
+			if err := l.tokensToEOL(); err != nil {
+				return err
+			}
+			continue
+		}
+		// Text runs to the next interpolation or the end of the line. A
+		// doubled "{{{{" is an escaped "{{", and stays in the text.
+		n := 0
+		for l.pos+n < len(l.src) && l.src[l.pos+n] != '\n' && !bytes.HasPrefix(l.src[l.pos+n:], []byte("\r\n")) {
+			if bytes.HasPrefix(l.src[l.pos+n:], []byte("{{{{")) {
+				n += 4
+				continue
+			}
+			if bytes.HasPrefix(l.src[l.pos+n:], []byte("{{")) {
+				break
+			}
+			n++
+		}
+		l.emit(Text, n)
+	}
+	return nil
+}
+
+// tokensToEOL lexes tokens up to and including the newline that ends the
+// line, or, inside an interpolation, up to the }} that closes it.
+func (l *lexer) tokensToEOL() error {
+	inInterpolation := len(l.open) > 0 && l.open[len(l.open)-1] == 'I'
+	for l.pos < len(l.src) {
+		top := byte(0)
+		if len(l.open) > 0 {
+			top = l.open[len(l.open)-1]
+		}
+		c := l.src[l.pos]
+
+		if n := l.newline(); n > 0 {
+			if inInterpolation {
+				return l.errorf("unterminated interpolation")
+			}
+			l.emit(Newline, n)
+			if len(l.open) == 0 {
+				return nil
+			}
+			continue
+		}
+
+		switch {
+		case c == ' ' || c == '\t':
+			l.emit(Whitespace, l.blanks())
+		case c == '\\' && (bytes.HasPrefix(l.rest(), []byte("\\\n")) || bytes.HasPrefix(l.rest(), []byte("\\\r\n"))):
+			// A line continuation joins the next line to this one.
+			n := 1
+			l.pos++
+			n += l.newline()
+			l.pos--
+			l.emit(Whitespace, n)
+		case c == '#':
+			n := bytes.IndexAny(l.rest(), "\r\n")
+			if n < 0 {
+				n = len(l.rest())
+			}
+			l.emit(Comment, n)
+		case isNameChar(c) && c != '-':
+			n := 1
+			for l.pos+n < len(l.src) && isNameChar(l.src[l.pos+n]) {
+				n++
+			}
+			l.emit(Name, n)
+		case c == '"' || c == '\'':
+			if err := l.quoted(String, c); err != nil {
+				return err
+			}
+		case c == '`':
+			if err := l.quoted(Backtick, c); err != nil {
+				return err
+			}
+		case top == 'I' && bytes.HasPrefix(l.rest(), []byte("}}")):
+			l.emit(InterpolationEnd, 2)
+			l.open = l.open[:len(l.open)-1]
+			return nil
+		default:
+			if err := l.operator(top); err != nil {
+				return err
+			}
+		}
+	}
+	if inInterpolation {
+		return l.errorf("unterminated interpolation")
+	}
+	return nil
+}
+
+// operator lexes a punctuation token, tracking open delimiters.
+func (l *lexer) operator(top byte) error {
+	for _, op := range operators {
+		if !bytes.HasPrefix(l.rest(), []byte(op.text)) {
+			continue
+		}
+		switch op.kind {
+		case ParenL, BracketL, BraceL:
+			l.open = append(l.open, op.text[0])
+		case ParenR, BracketR, BraceR:
+			want := map[TokenKind]byte{ParenR: '(', BracketR: '[', BraceR: '{'}[op.kind]
+			if top != want {
+				return l.errorf("unexpected '%s'", op.text)
+			}
+			l.open = l.open[:len(l.open)-1]
+		}
+		l.emit(op.kind, len(op.text))
+		return nil
+	}
+	return l.errorf("unexpected character %q", l.src[l.pos])
+}
+
+// quoted lexes a string or backtick delimited by q, which may be tripled
+// for an indented string. Only double-quoted strings have escapes.
+func (l *lexer) quoted(kind TokenKind, q byte) error {
+	delim := []byte{q}
+	if bytes.HasPrefix(l.rest(), []byte{q, q, q}) {
+		delim = []byte{q, q, q}
+	}
+	for n := len(delim); l.pos+n < len(l.src); {
+		switch {
+		case q == '"' && l.src[l.pos+n] == '\\':
+			n += 2
+		case bytes.HasPrefix(l.src[l.pos+n:], delim):
+			l.emit(kind, n+len(delim))
+			return nil
+		default:
+			n++
+		}
+	}
+	if kind == Backtick {
+		return l.errorf("unterminated backtick")
+	}
+	return l.errorf("unterminated string")
+}
+
+func isNameChar(c byte) bool {
+	return c == '_' || c == '-' || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') || ('0' <= c && c <= '9')
+}
@@ -0,0 +1,188 @@
+package justfile
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// ListOptions controls the listing ListRecipes produces.
+type ListOptions struct {
+	Heading  string // written before the recipes, verbatim; "" for none
+	Prefix   string // written before each recipe and group name
+	Unsorted bool   // keep recipes and groups in file order rather than by name
+	Colour   bool   // colour parameters, defaults and comments with ANSI escapes
+
+	// Submodules lists the recipes of each submodule under its name,
+	// indented by another four spaces, rather than just the name.
+	Submodules bool
+}
+
+// DefaultListOptions returns the options matching `just --list`.
+func DefaultListOptions() ListOptions {
+	return ListOptions{Heading: "Available recipes:\n", Prefix: "    "}
+}
+
+// maxListWidth caps the column comments are aligned to, so that one long
+// recipe signature doesn't push every comment to the right. Longer
+// signatures are followed by a single space.
+const maxListWidth = 50
+
+// ANSI escapes used by coloured listings.
+const (
+	ansiReset = "\x1b[0m"
+	ansiBlue  = "\x1b[34m"
+	ansiCyan  = "\x1b[36m"
+	ansiGreen = "\x1b[32m"
+)
+
+// listEntry is a recipe or submodule as shown in a listing: its
+// signature, plain and as written, the comment after it and, for a
+// submodule with ListOptions.Submodules, its own listing.
+type listEntry struct {
+	plain     string
+	signature string
+	comment   string
+	nested    string
+}
+
+// ListRecipes returns a human-readable list of recipes, similar to
+// `just --list`. Recipes without a [group] come first, followed by the
+// loaded submodules as "name ...", then each group under its name in
+// brackets; a recipe in several groups is listed in each. Comments hold
+// the first line of the doc and the aliases of the recipe, and are aligned
+// in a column.
+func ListRecipes(jf *Justfile, opts ListOptions) string {
+	paint := func(code, s string) string {
+		if !opts.Colour || s == "" {
+			return s
+		}
+		return code + s + ansiReset
+	}
+
+	aliases := make(map[string][]string)
+	for _, a := range jf.Aliases {
+		aliases[a.Target] = append(aliases[a.Target], a.Name)
+	}
+
+	var (
+		groups  []string // in order of first appearance
+		members = make(map[string][]*Recipe)
+	)
+	for i := range jf.Recipes {
+		r := &jf.Recipes[i]
+		if r.IsListDefault() {
+			continue
+		}
+		recipeGroups := r.Groups()
+		if len(recipeGroups) == 0 {
+			recipeGroups = []string{""}
+		}
+		for _, g := range recipeGroups {
+			if _, ok := members[g]; !ok && g != "" {
+				groups = append(groups, g)
+			}
+			members[g] = append(members[g], r)
+		}
+	}
+	if !opts.Unsorted {
+		slices.Sort(groups)
+		for _, rs := range members {
+			slices.SortStableFunc(rs, func(a, b *Recipe) int { return strings.Compare(a.Name, b.Name) })
+		}
+	}
+
+	entry := func(r *Recipe) listEntry {
+		e := listEntry{plain: r.Name, signature: r.Name}
+		for _, p := range r.Params {
+			name := p.Variadic + p.Name
+			e.plain += " " + name
+			e.signature += " " + paint(ansiCyan, name)
+			if p.HasDefault {
+				e.plain += "=" + p.DefaultSource()
+				e.signature += "=" + paint(ansiGreen, p.DefaultSource())
+			}
+		}
+
+		comment := r.Summary()
+		if names := aliases[r.Name]; len(names) > 0 {
+			label := "alias"
+			if len(names) > 1 {
+				label = "aliases"
+			}
+			if !opts.Unsorted {
+				names = slices.Sorted(slices.Values(names))
+			}
+			comment = strings.TrimSpace(comment + " [" + label + ": " + strings.Join(names, ", ") + "]")
+		}
+		if comment != "" {
+			e.comment = paint(ansiBlue, "# "+comment)
+		}
+		return e
+	}
+
+	sections := append([]string{""}, groups...)
+	entries := make(map[string][]listEntry)
+	for _, g := range sections {
+		for _, r := range members[g] {
+			entries[g] = append(entries[g], entry(r))
+		}
+	}
+
+	modules := slices.Clone(jf.Modules)
+	if !opts.Unsorted {
+		slices.SortStableFunc(modules, func(a, b Module) int { return strings.Compare(a.Name, b.Name) })
+	}
+	for _, m := range modules {
+		if m.Justfile == nil {
+			continue
+		}
+		e := listEntry{plain: m.Name + " ...", signature: m.Name + " ..."}
+		if opts.Submodules {
+			e.plain, e.signature = m.Name+":", m.Name+":"
+			sub := opts
+			sub.Heading = ""
+			sub.Prefix = opts.Prefix + "    "
+			e.nested = ListRecipes(m.Justfile, sub)
+		}
+		if summary, _, _ := strings.Cut(m.Doc, "\n"); summary != "" {
+			e.comment = paint(ansiBlue, "# "+summary)
+		}
+		entries[""] = append(entries[""], e)
+	}
+
+	width := 0
+	for _, g := range sections {
+		for _, e := range entries[g] {
+			if e.comment != "" && len(e.plain) <= maxListWidth {
+				width = max(width, len(e.plain))
+			}
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString(opts.Heading)
+	first := true
+	for _, g := range sections {
+		if len(entries[g]) == 0 {
+			continue
+		}
+		if g != "" {
+			if !first {
+				b.WriteString("\n")
+			}
+			fmt.Fprintf(&b, "%s[%s]\n", opts.Prefix, g)
+		}
+		first = false
+		for _, e := range entries[g] {
+			b.WriteString(opts.Prefix + e.signature)
+			if e.comment != "" {
+				pad := max(width-len(e.plain), 0) + 1
+				b.WriteString(strings.Repeat(" ", pad) + e.comment)
+			}
+			b.WriteString("\n")
+			b.WriteString(e.nested)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,184 @@
+package justfile
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// stringValue returns the value of a string token as just defines it.
+// Single-quoted strings are raw, double-quoted strings have escape
+// sequences, and tripled quotes make an indented string, whose common
+// indentation, first line break and trailing blank line are removed
+// before escapes are processed.
+func stringValue(text string) (string, error) {
+	delim := text[:1]
+	if len(text) >= 6 && strings.HasPrefix(text, strings.Repeat(delim, 3)) {
+		delim = strings.Repeat(delim, 3)
+	}
+	s := text[len(delim) : len(text)-len(delim)]
+	if len(delim) == 3 {
+		s = unindent(s)
+	}
+	if delim[0] == '"' {
+		return cook(s)
+	}
+	return s, nil
+}
+
+// backtickCommand returns the command of a backtick token. A tripled
+// backtick is indented like an indented string.
+func backtickCommand(text string) string {
+	if len(text) >= 6 && strings.HasPrefix(text, "```") {
+		return unindent(text[3 : len(text)-3])
+	}
+	return text[1 : len(text)-1]
+}
+
+// cook processes the escape sequences of a double-quoted string.
+func cook(s string) (string, error) {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' {
+			b.WriteByte(s[i])
+			continue
+		}
+		i++
+		if i == len(s) {
+			return "", fmt.Errorf(`string ends with an unescaped \`)
+		}
+		switch c := s[i]; c {
+		case 'n':
+			b.WriteByte('\n')
+		case 'r':
+			b.WriteByte('\r')
+		case 't':
+			b.WriteByte('\t')
+		case '\\', '"':
+			b.WriteByte(c)
+		case '\r', '\n':
+			// An escaped line break is removed, with the indentation of
+			// the line after it.
+			if c == '\r' && strings.HasPrefix(s[i:], "\r\n") {
+				i++
+			} else if c == '\r' {
+				return "", fmt.Errorf(`invalid escape sequence \r`)
+			}
+			for i+1 < len(s) && (s[i+1] == ' ' || s[i+1] == '\t') {
+				i++
+			}
+		case 'u':
+			hex, _, ok := strings.Cut(s[i+1:], "}")
+			r, err := strconv.ParseUint(strings.TrimPrefix(hex, "{"), 16, 32)
+			if !ok || !strings.HasPrefix(hex, "{") || len(hex) > 7 || err != nil || !utf8.ValidRune(rune(r)) {
+				return "", fmt.Errorf(`invalid unicode escape sequence, want \u{hex digits}`)
+			}
+			b.WriteRune(rune(r))
+			i += len(hex) + 1
+		default:
+			return "", fmt.Errorf(`invalid escape sequence \%c`, c)
+		}
+	}
+	return b.String(), nil
+}
+
+// unindent removes the indentation common to the non-blank lines of s. A
+// blank first or last line is removed, and other blank lines are kept
+// as line breaks.
+func unindent(s string) string {
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	common, found := "", false
+	for _, line := range lines {
+		if isBlank(line) {
+			continue
+		}
+		indent := line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+		if !found {
+			common, found = indent, true
+			continue
+		}
+		for !strings.HasPrefix(indent, common) {
+			common = common[:len(common)-1]
+		}
+	}
+
+	var b strings.Builder
+	for i, line := range lines {
+		switch {
+		case !isBlank(line):
+			b.WriteString(line[len(common):])
+		case i > 0 && i < len(lines)-1:
+			b.WriteString("\n")
+		}
+	}
+	return b.String()
+}
+
+func isBlank(line string) bool {
+	return strings.Trim(line, " \t\r\n") == ""
+}
+
+// Fragment is a piece of a recipe body line: literal text, or the
+// expression of a {{...}} interpolation.
+type Fragment struct {
+	Text          string // the text, or the expression without surrounding space
+	Interpolation bool
+}
+
+// Fragments splits a recipe body line into literal text and
+// interpolations. An escaped "{{{{" is literal text "{{", and "{{" with no
+// closing "}}" is literal.
+func Fragments(line string) []Fragment {
+	var (
+		frags []Fragment
+		text  strings.Builder
+	)
+	for len(line) > 0 {
+		i := strings.Index(line, "{{")
+		if i < 0 {
+			text.WriteString(line)
+			break
+		}
+		text.WriteString(line[:i])
+		line = line[i:]
+		if strings.HasPrefix(line, "{{{{") {
+			text.WriteString("{{")
+			line = line[4:]
+			continue
+		}
+		end := strings.Index(line[2:], "}}")
+		if end < 0 {
+			text.WriteString(line)
+			break
+		}
+		if text.Len() > 0 {
+			frags = append(frags, Fragment{Text: text.String()})
+			text.Reset()
+		}
+		frags = append(frags, Fragment{Text: strings.TrimSpace(line[2 : 2+end]), Interpolation: true})
+		line = line[4+end:]
+	}
+	if text.Len() > 0 {
+		frags = append(frags, Fragment{Text: text.String()})
+	}
+	return frags
+}
+
+// ExpandInterpolations returns line with each interpolation replaced by
+// f of its expression, and "{{{{" escapes by "{{".
+func ExpandInterpolations(line string, f func(expr string) string) string {
+	var b strings.Builder
+	for _, frag := range Fragments(line) {
+		if frag.Interpolation {
+			b.WriteString(f(frag.Text))
+		} else {
+			b.WriteString(frag.Text)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,89 @@
+package justfile
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestStringValue(t *testing.T) {
+	tests := []struct {
+		name, input, want string
+	}{
+		{"cooked tab", `"a\tb"`, "a\tb"},
+		{"cooked newline", `"line\n"`, "line\n"},
+		{"cooked quote and backslash", `"say \"hi\" \\o/"`, `say "hi" \o/`},
+		{"cooked carriage return", `"a\rb"`, "a\rb"},
+		{"unicode", `"\u{1F600}\u{e9}"`, "😀é"},
+		{"escaped line break", "\"one \\\n    two\"", "one two"},
+		{"raw", `'raw\n'`, `raw\n`},
+		{"raw with newline", "'a\nb'", "a\nb"},
+		{"empty", `""`, ""},
+		{"indented", "\"\"\"\n    one\n      two\n\n    three\n\"\"\"", "one\n  two\n\nthree\n"},
+		{"indented cooked", "\"\"\"\n  a\\tb\n  \"\"\"", "a\tb\n"},
+		{"indented raw", "'''\n  a\\tb\n'''", "a\\tb\n"},
+		{"indented on one line", `'''  a  '''`, "a  "},
+	}
+	for _, tt := range tests {
+		got, err := stringValue(tt.input)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.name, err)
+			continue
+		}
+		assertEqual(t, tt.name, got, tt.want)
+	}
+
+	for _, bad := range []string{`"\q"`, `"\u{110000}"`, `"\u1234"`, `"\u{}"`} {
+		if _, err := stringValue(bad); err == nil {
+			t.Errorf("%s: expected error", bad)
+		}
+	}
+}
+
+func TestParseStringSemantics(t *testing.T) {
+	input := `tab := "a\tb"
+raw := '\d+\n'
+cmd := ` + "```\n    echo one\n    echo two\n```" + `
+text := '''
+    first
+      second
+'''
+
+greet name="hello\tworld" sep='\n':
+	echo {{name}}{{sep}} '{{{{literal}}'
+`
+
+	jf, err := Parse(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	assertEqual(t, "cooked", jf.Variables[0].Value, "a\tb")
+	assertEqual(t, "raw", jf.Variables[1].Value, `\d+\n`)
+	assertEqual(t, "backtick", jf.Variables[2].Value, "echo one\necho two\n")
+	assertEqual(t, "indented", jf.Variables[3].Value, "first\n  second\n")
+
+	r := jf.Recipes[0]
+	assertEqual(t, "cooked default", r.Params[0].Default, "hello\tworld")
+	assertEqual(t, "raw default", r.Params[1].Default, `\n`)
+
+	got := ExpandInterpolations(r.Lines[0], func(expr string) string { return "<" + expr + ">" })
+	assertEqual(t, "expanded", got, "echo <name><sep> '{{literal}}'")
+
+	_, err = Parse(strings.NewReader("x := \"a\"\ny := \"bad \\q\"\n"))
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	assertEqual(t, "error", err.Error(), `line 2: invalid escape sequence \q`)
+}
+
+func TestFragments(t *testing.T) {
+	var parts []string
+	for _, f := range Fragments("a {{ x }} {{{{b}} {{y}}c {{ open") {
+		if f.Interpolation {
+			parts = append(parts, "<"+f.Text+">")
+		} else {
+			parts = append(parts, f.Text)
+		}
+	}
+	assertEqual(t, "fragments", strings.Join(parts, "|"), "a |<x>| {{b}} |<y>|c {{ open")
+}
@@ -0,0 +1,126 @@
+package justfile
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// Load reads and parses the justfile at path, then loads the justfiles of
+// its submodules, and theirs, into each Module. A module's justfile is
+// found relative to the directory of the justfile declaring it. Optional
+// modules whose justfile doesn't exist are dropped.
+func Load(path string) (*Justfile, error) {
+	return load(path, nil)
+}
+
+// load loads the justfile at path, whose parent modules are loaded from
+// the paths in stack.
+func load(path string, stack []string) (*Justfile, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range stack {
+		if p == abs {
+			return nil, fmt.Errorf("%s: module cycle: %s", path, strings.Join(append(stack, abs), " -> "))
+		}
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("opening justfile: %w", err)
+	}
+	defer f.Close()
+
+	jf, err := Parse(f)
+	if err != nil {
+		if len(stack) > 0 {
+			// The root justfile's errors are reported without its path.
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		return nil, err
+	}
+
+	dir := filepath.Dir(path)
+	modules := jf.Modules[:0]
+	for _, m := range jf.Modules {
+		modPath, err := modulePath(dir, m)
+		if err != nil {
+			return nil, fmt.Errorf("%s: line %d: %w", path, m.Line, err)
+		}
+		if modPath == "" {
+			continue
+		}
+		if m.Justfile, err = load(modPath, append(stack, abs)); err != nil {
+			return nil, err
+		}
+		modules = append(modules, m)
+	}
+	jf.Modules = modules
+	return jf, nil
+}
+
+// modulePath returns the path of a module's justfile, declared in a
+// justfile in dir, or "" if an optional module has none. As in just, a
+// module named foo is foo.just, foo/mod.just, foo/justfile or
+// foo/.justfile; a path given with the declaration may name the file or a
+// directory holding one of the last three.
+func modulePath(dir string, m Module) (string, error) {
+	var candidates []string
+	switch {
+	case m.Path == "":
+		candidates = []string{m.Name + ".just"}
+		for _, name := range []string{"mod.just", "justfile", "Justfile", ".justfile"} {
+			candidates = append(candidates, filepath.Join(m.Name, name))
+		}
+	default:
+		p := m.Path
+		if strings.HasPrefix(p, "~/") {
+			home, err := os.UserHomeDir()
+			if err != nil {
+				return "", err
+			}
+			p = filepath.Join(home, p[2:])
+		}
+		if !filepath.IsAbs(p) {
+			p = filepath.Join(dir, p)
+		}
+		if fi, err := os.Stat(p); err != nil || !fi.IsDir() {
+			candidates = []string{p}
+			break
+		}
+		for _, name := range []string{"mod.just", "justfile", "Justfile", ".justfile"} {
+			candidates = append(candidates, filepath.Join(p, name))
+		}
+	}
+
+	var (
+		found []string
+		infos []os.FileInfo
+	)
+	for _, c := range candidates {
+		if !filepath.IsAbs(c) {
+			c = filepath.Join(dir, c)
+		}
+		fi, err := os.Stat(c)
+		// On case-insensitive file systems justfile and Justfile are the
+		// same file.
+		if err != nil || fi.IsDir() || slices.ContainsFunc(infos, func(o os.FileInfo) bool { return os.SameFile(o, fi) }) {
+			continue
+		}
+		found = append(found, c)
+		infos = append(infos, fi)
+	}
+	switch {
+	case len(found) > 1:
+		return "", fmt.Errorf("module '%s' is ambiguous: found %s", m.Name, strings.Join(found, " and "))
+	case len(found) == 1:
+		return found[0], nil
+	case m.Optional:
+		return "", nil
+	}
+	return "", fmt.Errorf("module '%s': no justfile found", m.Name)
+}
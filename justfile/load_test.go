@@ -0,0 +1,120 @@
+package justfile
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeTestFiles writes files, named by slash-separated paths, under dir.
+func writeTestFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestParseModules(t *testing.T) {
+	input := `# Web front end
+mod frontend
+mod? extras "tools/extras.just"
+`
+	jf, err := Parse(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	assertEqual(t, "modules", len(jf.Modules), 2)
+	assertEqual(t, "name", jf.Modules[0].Name, "frontend")
+	assertEqual(t, "doc", jf.Modules[0].Doc, "Web front end")
+	assertEqual(t, "path", jf.Modules[1].Path, "tools/extras.just")
+	assertEqual(t, "optional", jf.Modules[1].Optional, true)
+	assertEqual(t, "line", jf.Modules[1].Line, 3)
+}
+
+func TestLoadModules(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFiles(t, dir, map[string]string{
+		"justfile": `mod frontend
+mod tools "scripts/tools.just"
+mod? missing
+
+build:
+    true
+`,
+		"frontend/justfile":    "mod ui\n\nserve:\n    true\n",
+		"frontend/ui/mod.just": "storybook:\n    true\n",
+		"scripts/tools.just":   "fmt:\n    true\n",
+	})
+
+	jf, err := Load(filepath.Join(dir, "justfile"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	assertEqual(t, "modules", len(jf.Modules), 2)
+	frontend := jf.FindModule("frontend").Justfile
+	assertEqual(t, "frontend recipe", frontend.Recipes[0].Name, "serve")
+	assertEqual(t, "nested recipe", frontend.FindModule("ui").Justfile.Recipes[0].Name, "storybook")
+	assertEqual(t, "path recipe", jf.FindModule("tools").Justfile.Recipes[0].Name, "fmt")
+	assertEqual(t, "optional dropped", jf.FindModule("missing") == nil, true)
+
+	assertEqual(t, "list", ListRecipes(jf, DefaultListOptions()), `Available recipes:
+    build
+    frontend ...
+    tools ...
+`)
+	opts := DefaultListOptions()
+	opts.Submodules = true
+	assertEqual(t, "tree", ListRecipes(jf, opts), `Available recipes:
+    build
+    frontend:
+        serve
+        ui:
+            storybook
+    tools:
+        fmt
+`)
+}
+
+func TestLoadModuleErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+		want  string
+	}{
+		{
+			name:  "missing",
+			files: map[string]string{"justfile": "mod web\n"},
+			want:  "line 1: module 'web': no justfile found",
+		},
+		{
+			name:  "ambiguous",
+			files: map[string]string{"justfile": "mod web\n", "web.just": "", "web/mod.just": ""},
+			want:  "line 1: module 'web' is ambiguous",
+		},
+		{
+			name:  "cycle",
+			files: map[string]string{"justfile": "mod a\n", "a.just": "mod a 'a.just'\n"},
+			want:  "module cycle",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeTestFiles(t, dir, tt.files)
+			_, err := Load(filepath.Join(dir, "justfile"))
+			if err == nil {
+				t.Fatal("expected error")
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("error %q does not contain %q", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,528 @@
+// Package justfile parses justfiles into a Justfile, and formats them back
+// into source.
+//
+// Parsing follows just's grammar: a lexer splits the source into tokens,
+// and a recursive-descent parser builds a concrete syntax tree from them,
+// which Parse reduces to the subset jmake supports: variable assignments
+// (optionally exported, with backtick values), settings, aliases,
+// attributes, and recipes with parameters, dependencies and doc comments.
+//
+// ParseCST gives a lossless concrete syntax tree instead, for tools that
+// rewrite justfiles and need comments, blank lines and positions intact.
+package justfile
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+)
+
+// Param represents a recipe parameter.
+type Param struct {
+	Name        string
+	Default     string      // the default, if HasDefault, read as DefaultKind says
+	DefaultKind DefaultKind // what Default holds
+	HasDefault  bool        // false if required; a default may be empty
+	Variadic    string      // "" | "*" | "+"
+}
+
+// DefaultKind says how to read a parameter's Default.
+type DefaultKind int
+
+const (
+	DefaultValue    DefaultKind = iota // the value itself
+	DefaultVariable                    // the name of an earlier parameter or a variable
+	DefaultBacktick                    // a command whose output is the value
+)
+
+// Variable represents a top-level variable assignment.
+type Variable struct {
+	Name     string
+	Line     int // line number of the assignment
+	Value    string
+	Export   bool
+	Backtick bool // value is a backtick command
+}
+
+// Setting is a top-level setting such as set shell := ["bash", "-c"]. The
+// value of a boolean setting written without one, as in set quiet, is
+// "true"; a list value is its elements joined with spaces.
+type Setting struct {
+	Name  string
+	Value string
+	Line  int
+}
+
+// Alias maps one name to another recipe.
+type Alias struct {
+	Name   string
+	Target string
+	Line   int // line number of the alias declaration
+}
+
+// Attribute is a recipe attribute such as [private] or [inputs("src/*.go")].
+type Attribute struct {
+	Name string
+	Args []string
+}
+
+// Recipe represents a justfile recipe.
+type Recipe struct {
+	Name         string
+	Line         int    // line number of the recipe header; body lines follow it
+	Doc          string // doc comment block before the header, or from [doc("...")]
+	Params       []Param
+	Dependencies []string
+	Lines        []string // body lines (indented commands)
+	Silent       bool     // all lines prefixed with @
+	Attributes   []Attribute
+	Inputs       []string // globs from [inputs(...)]
+	Outputs      []string // files from [outputs(...)]
+
+	// bodyLines holds the justfile line of each of Lines, which are not
+	// consecutive when the body has blank lines or continuations.
+	bodyLines []int
+}
+
+// Summary returns the first line of the recipe's doc comment, for
+// listings.
+func (r *Recipe) Summary() string {
+	summary, _, _ := strings.Cut(r.Doc, "\n")
+	return summary
+}
+
+// Incremental reports whether the recipe declares outputs, which are
+// generated as real file targets rather than phony ones.
+func (r *Recipe) Incremental() bool {
+	return len(r.Outputs) > 0
+}
+
+// BodyLine returns the justfile line number of body line i, or 0 if the
+// recipe's position is unknown.
+func (r *Recipe) BodyLine(i int) int {
+	if i < len(r.bodyLines) {
+		return r.bodyLines[i]
+	}
+	if r.Line == 0 {
+		return 0
+	}
+	return r.Line + 1 + i
+}
+
+// Cacheable reports whether the recipe declares inputs, so jmake can skip
+// it when its fingerprint is unchanged since the last successful run.
+func (r *Recipe) Cacheable() bool {
+	return len(r.Inputs) > 0
+}
+
+// Module is a submodule declared with `mod name` or `mod name "path"`.
+type Module struct {
+	Name     string
+	Path     string // as given after the name, relative to the justfile; "" to search for it
+	Optional bool   // declared with mod?, so a missing justfile is not an error
+	Doc      string // doc comment block before the declaration
+	Line     int    // line number of the declaration
+
+	// Justfile is the module's justfile, once loaded by Load.
+	Justfile *Justfile
+}
+
+// Justfile is the parsed representation of a justfile.
+type Justfile struct {
+	Variables []Variable
+	Settings  []Setting
+	Recipes   []Recipe
+	Aliases   []Alias
+	Modules   []Module
+}
+
+// Parse reads a justfile from r and returns a structured Justfile.
+func Parse(r io.Reader) (*Justfile, error) {
+	src, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("reading justfile: %w", err)
+	}
+	root, err := ParseCST(src)
+	if err != nil {
+		return nil, err
+	}
+
+	jf := &Justfile{}
+	var (
+		pendingDoc   []string // comment lines, without their #
+		pendingAttrs []Attribute
+	)
+	for _, n := range root.Children {
+		line := lineOf(src, n.Start())
+		toks := significant(n)
+
+		switch n.Kind {
+		case BlankNode:
+			// Blank line resets pending doc and attributes.
+			pendingDoc = nil
+			pendingAttrs = nil
+			continue
+
+		case CommentNode:
+			// Consecutive comment lines form a doc block. Section
+			// separators are not doc comments, and end any block before.
+			text := strings.TrimPrefix(commentText(n), "#")
+			pendingDoc = append(pendingDoc, text)
+			if isSectionSeparator(strings.TrimSpace(text)) {
+				pendingDoc = nil
+			}
+			continue
+
+		case AttributeNode:
+			// Attributes apply to the next recipe header.
+			attrs, err := lowerAttributes(src, n)
+			if err != nil {
+				return nil, err
+			}
+			pendingAttrs = append(pendingAttrs, attrs...)
+			continue
+
+		case AliasNode:
+			jf.Aliases = append(jf.Aliases, Alias{Name: toks[1].Text, Target: toks[3].Text, Line: line})
+
+		case AssignmentNode:
+			v := Variable{Name: toks[0].Text, Line: line}
+			if toks[0].Text == "export" && toks[1].Kind == Name {
+				v.Export, v.Name = true, toks[1].Text
+			}
+			var err error
+			if v.Value, v.Backtick, err = expressionValue(src, child(n, ExpressionNode)); err != nil {
+				return nil, err
+			}
+			jf.Variables = append(jf.Variables, v)
+
+		case SettingNode:
+			s := Setting{Name: toks[1].Text, Value: "true", Line: line}
+			if len(toks) > 3 {
+				var err error
+				if s.Value, err = settingValue(src, n, toks[3:]); err != nil {
+					return nil, err
+				}
+			}
+			jf.Settings = append(jf.Settings, s)
+
+		case ImportNode:
+			return nil, fmt.Errorf("line %d: import is not supported", line)
+
+		case ModuleNode:
+			m := Module{Doc: docBlock(pendingDoc), Line: line}
+			for _, t := range toks[1:] {
+				switch t.Kind {
+				case QuestionMark:
+					m.Optional = true
+				case Name:
+					m.Name = t.Text
+				case String:
+					var err error
+					if m.Path, err = tokenString(src, t); err != nil {
+						return nil, err
+					}
+				}
+			}
+			if jf.FindModule(m.Name) != nil {
+				return nil, fmt.Errorf("line %d: module '%s' is declared twice", line, m.Name)
+			}
+			jf.Modules = append(jf.Modules, m)
+
+		case RecipeNode:
+			recipe, err := lowerRecipe(src, n)
+			if err != nil {
+				return nil, err
+			}
+			recipe.Doc = docBlock(pendingDoc)
+			recipe.applyAttributes(pendingAttrs)
+			jf.Recipes = append(jf.Recipes, recipe)
+		}
+		// Doc comments and attributes only carry over to the item that
+		// follows them.
+		pendingDoc = nil
+		pendingAttrs = nil
+	}
+
+	// A default may name an earlier parameter or a variable assigned
+	// anywhere in the file.
+	for _, r := range jf.Recipes {
+		for i, p := range r.Params {
+			if p.DefaultKind != DefaultVariable || jf.FindVariable(p.Default) != nil {
+				continue
+			}
+			if !slices.ContainsFunc(r.Params[:i], func(q Param) bool { return q.Name == p.Default }) {
+				return nil, fmt.Errorf("line %d: recipe '%s': variable '%s' not defined", r.Line, r.Name, p.Default)
+			}
+		}
+	}
+	return jf, nil
+}
+
+// docBlock joins the lines of a doc comment block, without the indentation
+// they have in common or trailing space.
+func docBlock(lines []string) string {
+	if len(lines) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	for _, line := range lines {
+		b.WriteString(strings.TrimRight(line, " \t\r"))
+		b.WriteString("\n")
+	}
+	return strings.TrimSuffix(unindent(b.String()), "\n")
+}
+
+// lowerRecipe builds a Recipe from a RecipeNode, without its doc comment
+// and attributes, which come from the nodes before it.
+func lowerRecipe(src []byte, n *Node) (Recipe, error) {
+	r := Recipe{Line: lineOf(src, n.Start())}
+	for _, c := range n.Children {
+		switch c.Kind {
+		case TokenNode:
+			switch {
+			case c.Token.Kind == At:
+				r.Silent = true
+			case c.Token.Kind == Name && r.Name == "":
+				r.Name = c.Token.Text
+			case c.Token.Kind == AmpersandAmpersand:
+				return r, fmt.Errorf("line %d: recipe '%s': dependencies after && are not supported", r.Line, r.Name)
+			}
+
+		case ParameterNode:
+			// The name and kind are direct tokens of the node; names in
+			// the default belong to its expression.
+			var p Param
+			for _, t := range c.Children {
+				if t.Kind != TokenNode {
+					continue
+				}
+				switch t.Token.Kind {
+				case Asterisk, Plus:
+					p.Variadic = t.Token.Text
+				case Name:
+					p.Name = t.Token.Text
+				}
+			}
+			if e := child(c, ExpressionNode); e != nil {
+				var err error
+				if p.Default, p.DefaultKind, err = paramDefault(src, e); err != nil {
+					return r, fmt.Errorf("line %d: recipe '%s': parameter '%s': %w", lineOf(src, c.Start()), r.Name, p.Name, err)
+				}
+				p.HasDefault = true
+			}
+			r.Params = append(r.Params, p)
+
+		case DependencyNode:
+			if child(c, ExpressionNode) != nil {
+				return r, fmt.Errorf("line %d: recipe '%s': dependency arguments are not supported", lineOf(src, c.Start()), r.Name)
+			}
+			for _, t := range significant(c) {
+				if t.Kind == Name {
+					r.Dependencies = append(r.Dependencies, t.Text)
+					break
+				}
+			}
+
+		case BodyLineNode:
+			// A line ending in \ continues on the next, which is joined to
+			// it without its indentation, as just does.
+			text := strings.TrimRight(strings.TrimPrefix(c.String(), c.Children[0].Token.Text), "\r\n")
+			last := len(r.Lines) - 1
+			if last >= 0 && strings.HasSuffix(r.Lines[last], "\\") {
+				r.Lines[last] = strings.TrimSuffix(r.Lines[last], "\\") + strings.TrimLeft(text, " \t")
+				continue
+			}
+			r.Lines = append(r.Lines, text)
+			r.bodyLines = append(r.bodyLines, lineOf(src, c.Start()))
+		}
+	}
+	return r, nil
+}
+
+// lowerAttributes returns the attributes on an attribute line.
+func lowerAttributes(src []byte, n *Node) ([]Attribute, error) {
+	var (
+		attrs []Attribute
+		depth int
+	)
+	for _, t := range significant(n) {
+		switch t.Kind {
+		case ParenL:
+			depth++
+		case ParenR:
+			depth--
+		case Name:
+			if depth == 0 {
+				attrs = append(attrs, Attribute{Name: t.Text})
+			}
+		case String:
+			arg, err := tokenString(src, t)
+			if err != nil {
+				return nil, err
+			}
+			a := &attrs[len(attrs)-1]
+			a.Args = append(a.Args, arg)
+		}
+	}
+	return attrs, nil
+}
+
+// settingValue returns the value of a setting from the tokens after its :=.
+func settingValue(src []byte, n *Node, toks []Token) (string, error) {
+	if toks[0].Kind != BracketL {
+		value, _, err := expressionValue(src, child(n, ExpressionNode))
+		return value, err
+	}
+	var elems []string
+	for _, t := range toks {
+		if t.Kind == String {
+			elem, err := tokenString(src, t)
+			if err != nil {
+				return "", err
+			}
+			elems = append(elems, elem)
+		}
+	}
+	return strings.Join(elems, " "), nil
+}
+
+// paramDefault returns a parameter's default and how to read it. Backends
+// can evaluate a string, a variable or a backtick; other expressions are
+// rejected, since their source text is not their value.
+func paramDefault(src []byte, n *Node) (string, DefaultKind, error) {
+	toks := significant(n)
+	switch {
+	case len(toks) == 1 && toks[0].Kind == Name:
+		return toks[0].Text, DefaultVariable, nil
+	case len(toks) == 1 && toks[0].Kind == Backtick:
+		return backtickCommand(toks[0].Text), DefaultBacktick, nil
+	}
+	value, constant, err := constantValue(src, toks)
+	if err != nil {
+		return "", 0, err
+	}
+	if !constant {
+		return "", 0, fmt.Errorf("default %s is not supported; use a string, a variable or a backtick", strings.TrimSpace(n.String()))
+	}
+	return value, DefaultValue, nil
+}
+
+// constantValue returns the value of strings joined with + and /, and
+// whether toks are only that.
+func constantValue(src []byte, toks []Token) (string, bool, error) {
+	var b strings.Builder
+	for i, t := range toks {
+		switch {
+		case i%2 == 0 && t.Kind == String:
+			s, err := tokenString(src, t)
+			if err != nil {
+				return "", false, err
+			}
+			b.WriteString(s)
+		case i%2 == 1 && t.Kind == Slash:
+			b.WriteString("/")
+		case i%2 == 1 && t.Kind == Plus:
+		default:
+			return "", false, nil
+		}
+	}
+	return b.String(), len(toks)%2 == 1, nil
+}
+
+// expressionValue returns the value of an expression jmake can evaluate
+// without running anything: a string, a backtick, or strings joined with +
+// and /. A backtick's value is its command. Other expressions, such as
+// variable references and function calls, are returned as source text
+// on one line, for the backend to interpret.
+func expressionValue(src []byte, n *Node) (value string, backtick bool, err error) {
+	toks := significant(n)
+	if len(toks) == 1 && toks[0].Kind == Backtick {
+		return backtickCommand(toks[0].Text), true, nil
+	}
+	if value, constant, err := constantValue(src, toks); err != nil || constant {
+		return value, false, err
+	}
+
+	// Trivia between tokens, newlines and comments included, becomes a
+	// single space.
+	var b strings.Builder
+	space := false
+	for _, t := range n.Tokens() {
+		if t.trivia() || t.Kind == Newline {
+			space = b.Len() > 0
+			continue
+		}
+		if space {
+			b.WriteString(" ")
+			space = false
+		}
+		b.WriteString(t.Text)
+	}
+	return b.String(), false, nil
+}
+
+// significant returns the tokens under n that are not trivia.
+func significant(n *Node) []Token {
+	var toks []Token
+	for _, t := range n.Tokens() {
+		if !t.trivia() && t.Kind != Newline && t.Kind != EOF {
+			toks = append(toks, t)
+		}
+	}
+	return toks
+}
+
+// child returns the first child of n of the kind, or nil.
+func child(n *Node, kind NodeKind) *Node {
+	for _, c := range n.Children {
+		if c.Kind == kind {
+			return c
+		}
+	}
+	return nil
+}
+
+// commentText returns the text of the first comment under n.
+func commentText(n *Node) string {
+	for _, t := range n.Tokens() {
+		if t.Kind == Comment {
+			return t.Text
+		}
+	}
+	return ""
+}
+
+// isSectionSeparator reports whether comment text, without its #, is a
+// section separator like "--- Section ---".
+func isSectionSeparator(text string) bool {
+	return len(text) >= 6 && strings.HasPrefix(text, "---") && strings.HasSuffix(text, "---")
+}
+
+// applyAttributes records attrs on the recipe and fills in the fields
+// derived from attributes jmake understands.
+func (r *Recipe) applyAttributes(attrs []Attribute) {
+	r.Attributes = attrs
+	for _, a := range attrs {
+		switch a.Name {
+		case "doc":
+			// [doc("text")] replaces the doc comment; [doc] removes it.
+			r.Doc = strings.Join(a.Args, "\n")
+		case "inputs":
+			r.Inputs = append(r.Inputs, a.Args...)
+		case "outputs":
+			r.Outputs = append(r.Outputs, a.Args...)
+		}
+	}
+}
+
+// tokenString returns the value of a string token, with the token's line
+// in any error.
+func tokenString(src []byte, t Token) (string, error) {
+	s, err := stringValue(t.Text)
+	if err != nil {
+		return "", fmt.Errorf("line %d: %w", lineOf(src, t.Offset), err)
+	}
+	return s, nil
+}
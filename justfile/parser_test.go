@@ -1,4 +1,4 @@
-package main
+package justfile
 
 import (
 	"strings"
@@ -116,7 +116,14 @@ func TestParseRecipeWithParams(t *testing.T) {
 			input: `deploy env="staging":
 	echo deploying to {{env}}
 `,
-			wantParams: []Param{{Name: "env", Default: "staging"}},
+			wantParams: []Param{{Name: "env", Default: "staging", HasDefault: true}},
+		},
+		{
+			name: "empty default",
+			input: `greet name="":
+	echo hello {{name}}
+`,
+			wantParams: []Param{{Name: "name", HasDefault: true}},
 		},
 	}
 
@@ -141,6 +148,7 @@ func TestParseRecipeWithParams(t *testing.T) {
 				assertEqual(t, "param name", got.Name, want.Name)
 				assertEqual(t, "param variadic", got.Variadic, want.Variadic)
 				assertEqual(t, "param default", got.Default, want.Default)
+				assertEqual(t, "param has default", got.HasDefault, want.HasDefault)
 			}
 		})
 	}
@@ -203,6 +211,57 @@ build:
 	assertEqual(t, "doc", r.Doc, "Compile the binary")
 }
 
+func TestParseDocBlocks(t *testing.T) {
+	input := `# --- Deploy ---
+# Deploy the app.
+#
+# Steps:
+#   - build
+#   - ship
+deploy:
+	true
+
+# Not a doc: attributes override it.
+[doc("Compile it")]
+build:
+	true
+
+# Hidden.
+[doc]
+clean:
+	true
+
+[doc("Line one\nLine two")]
+lint:
+	true
+`
+
+	jf, err := Parse(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	deploy := findTestRecipe(t, jf, "deploy")
+	assertEqual(t, "deploy doc", deploy.Doc, "Deploy the app.\n\nSteps:\n  - build\n  - ship")
+	assertEqual(t, "deploy summary", deploy.Summary(), "Deploy the app.")
+	assertEqual(t, "build doc", findTestRecipe(t, jf, "build").Doc, "Compile it")
+	assertEqual(t, "clean doc", findTestRecipe(t, jf, "clean").Doc, "")
+	assertEqual(t, "lint summary", findTestRecipe(t, jf, "lint").Summary(), "Line one")
+
+	list := ListRecipes(jf, DefaultListOptions())
+	assertEqual(t, "listed summary", strings.Contains(list, "# Deploy the app.\n"), true)
+	assertEqual(t, "rest not listed", strings.Contains(list, "Steps"), false)
+
+	assertEqual(t, "show", FormatRecipe(deploy), `# Deploy the app.
+#
+# Steps:
+#   - build
+#   - ship
+deploy:
+    true
+`)
+}
+
 func TestParseDefaultListRecipe(t *testing.T) {
 	input := `# Default recipe - show available commands
 default:
@@ -215,7 +274,7 @@ default:
 	}
 
 	r := jf.Recipes[0]
-	if !isListDefault(&r) {
+	if !r.IsListDefault() {
 		t.Error("expected default recipe to be detected as list default")
 	}
 }
@@ -321,7 +380,7 @@ open:
 	}
 
 	// Check default recipe is list.
-	if !isListDefault(&jf.Recipes[0]) {
+	if !jf.Recipes[0].IsListDefault() {
 		t.Error("expected first recipe to be list default")
 	}
 
@@ -348,58 +407,17 @@ open:
 	assertEqual(t, "dev doc", dev.Doc, "Run the desktop app in development mode")
 }
 
-func TestConvertLine(t *testing.T) {
-	tests := []struct {
-		name  string
-		input string
-		want  string
-	}{
-		{
-			name:  "interpolation",
-			input: "echo {{NAME}}",
-			want:  "echo $(NAME)",
-		},
-		{
-			name:  "backtick in body",
-			input: "echo `git describe`",
-			want:  "echo $(shell git describe)",
-		},
-		{
-			name:  "mixed",
-			input: "deploy {{ENV}} `date`",
-			want:  "deploy $(ENV) $(shell date)",
-		},
-		{
-			name:  "no conversion needed",
-			input: "go build ./...",
-			want:  "go build ./...",
-		},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			got := convertLine(tt.input)
-			assertEqual(t, "converted line", got, tt.want)
-		})
-	}
-}
-
-func TestGenerateBrainiacMakefile(t *testing.T) {
-	input := `# Default recipe - show available commands
-default:
+func TestListRecipes(t *testing.T) {
+	input := `default:
     @just --list
 
-# Build the project
+# Build it
 build:
-    go build ./...
-
-# Run tests
-test:
-    go test ./...
+    go build
 
-# Run the CLI
-cli *ARGS:
-    go run . -- {{ARGS}}
+# Run with args
+run *ARGS:
+    go run . {{ARGS}}
 `
 
 	jf, err := Parse(strings.NewReader(input))
@@ -407,59 +425,92 @@ cli *ARGS:
 		t.Fatalf("unexpected error: %v", err)
 	}
 
-	output := Generate(jf, true)
+	output := ListRecipes(jf, DefaultListOptions())
 
-	// Check header.
-	if !strings.Contains(output, "# Generated by jmake") {
-		t.Error("missing header comment")
+	if !strings.Contains(output, "Available recipes:") {
+		t.Error("missing header")
 	}
-	if !strings.Contains(output, "SHELL := /bin/bash") {
-		t.Error("missing SHELL assignment")
+	if !strings.Contains(output, "build") {
+		t.Error("missing build recipe")
 	}
-
-	// Check .PHONY includes help.
-	if !strings.Contains(output, ".PHONY:") {
-		t.Error("missing .PHONY")
+	if !strings.Contains(output, "Build it") {
+		t.Error("missing build doc")
 	}
-	if !strings.Contains(output, "help") {
-		t.Error("missing help in .PHONY")
+	if !strings.Contains(output, "*ARGS") {
+		t.Error("missing variadic param display")
 	}
-
-	// Check help target exists.
-	if !strings.Contains(output, "help:") {
-		t.Error("missing help target")
+	// Default list recipe should be excluded.
+	if strings.Contains(output, "default") {
+		t.Error("default list recipe should be excluded from listing")
 	}
+}
 
-	// Check recipe conversion.
-	if !strings.Contains(output, "build:") {
-		t.Error("missing build target")
-	}
-	if !strings.Contains(output, "\tgo build ./...") {
-		t.Error("missing build command")
-	}
+func TestRecipeHelp(t *testing.T) {
+	input := `alias d := deploy
 
-	// Check interpolation conversion.
-	if !strings.Contains(output, "$(ARGS)") {
-		t.Error("{{ARGS}} not converted to $(ARGS)")
-	}
+# Deploy the app.
+#
+# Needs credentials for env.
+[group('ops')]
+deploy env region="us-east-1" +targets: build test
+    ./deploy {{env}} {{region}} {{targets}}
+
+build:
+    go build
+
+test:
+    go test
+`
 
-	// Default recipe (just --list) should not appear.
-	if strings.Contains(output, "default:") {
-		t.Error("default recipe should have been replaced by help")
+	jf, err := Parse(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
 	}
+
+	assertEqual(t, "help", RecipeHelp(jf, jf.FindRecipe("deploy"), "jmake"), `Usage: jmake deploy <env> [region] <targets>...
+
+Deploy the app.
+
+Needs credentials for env.
+
+Parameters:
+    env      required
+    region   default "us-east-1"
+    targets  one or more values
+
+Dependencies: build test
+Aliases: d
+Group: ops
+`)
+	assertEqual(t, "bare help", RecipeHelp(jf, jf.FindRecipe("build"), "jmake"), "Usage: jmake build\n")
+
+	r := Recipe{Name: "run", Params: []Param{{Name: "ARGS", Variadic: "*"}}}
+	assertEqual(t, "variadic usage", r.Usage("jmake"), "jmake run [ARGS]...")
 }
 
-func TestListRecipes(t *testing.T) {
-	input := `default:
-    @just --list
+func TestListRecipesGroupsAndAliases(t *testing.T) {
+	input := `alias t := test
+alias b := build
+alias c := build
+
+# Run the tests
+[group('dev')]
+test:
+    go test
+
+# Deploy somewhere
+[group('ops')]
+deploy env="prod" *flags:
+    ./deploy {{env}} {{flags}}
 
 # Build it
 build:
     go build
 
-# Run with args
-run *ARGS:
-    go run . {{ARGS}}
+# Lint it
+[group('dev')]
+lint:
+    golangci-lint run
 `
 
 	jf, err := Parse(strings.NewReader(input))
@@ -467,27 +518,35 @@ run *ARGS:
 		t.Fatalf("unexpected error: %v", err)
 	}
 
-	output := ListRecipes(jf)
+	assertEqual(t, "sorted", ListRecipes(jf, DefaultListOptions()), `Available recipes:
+    build                    # Build it [aliases: b, c]
 
-	if !strings.Contains(output, "Available recipes:") {
-		t.Error("missing header")
-	}
-	if !strings.Contains(output, "build") {
-		t.Error("missing build recipe")
-	}
-	if !strings.Contains(output, "Build it") {
-		t.Error("missing build doc")
-	}
-	if !strings.Contains(output, "*ARGS") {
-		t.Error("missing variadic param display")
-	}
-	// Default list recipe should be excluded.
-	if strings.Contains(output, "default") {
-		t.Error("default list recipe should be excluded from listing")
-	}
+    [dev]
+    lint                     # Lint it
+    test                     # Run the tests [alias: t]
+
+    [ops]
+    deploy env="prod" *flags # Deploy somewhere
+`)
+
+	unsorted := ListOptions{Heading: "Recipes:\n", Prefix: "  ", Unsorted: true}
+	assertEqual(t, "unsorted", ListRecipes(jf, unsorted), `Recipes:
+  build                    # Build it [aliases: b, c]
+
+  [dev]
+  test                     # Run the tests [alias: t]
+  lint                     # Lint it
+
+  [ops]
+  deploy env="prod" *flags # Deploy somewhere
+`)
+
+	coloured := ListRecipes(jf, ListOptions{Colour: true})
+	assertEqual(t, "coloured signature", strings.Contains(coloured,
+		"deploy \x1b[36menv\x1b[0m=\x1b[32m\"prod\"\x1b[0m \x1b[36m*flags\x1b[0m \x1b[34m# Deploy somewhere\x1b[0m\n"), true)
 }
 
-func TestMapArgs(t *testing.T) {
+func TestBindArgs(t *testing.T) {
 	tests := []struct {
 		name    string
 		recipe  Recipe
@@ -544,7 +603,16 @@ func TestMapArgs(t *testing.T) {
 			name: "default value used when no arg",
 			recipe: Recipe{
 				Name:   "deploy",
-				Params: []Param{{Name: "env", Default: "staging"}},
+				Params: []Param{{Name: "env", Default: "staging", HasDefault: true}},
+			},
+			args: nil,
+			want: nil,
+		},
+		{
+			name: "empty default is optional",
+			recipe: Recipe{
+				Name:   "greet",
+				Params: []Param{{Name: "name", HasDefault: true}},
 			},
 			args: nil,
 			want: nil,
@@ -553,7 +621,7 @@ func TestMapArgs(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := mapArgs(&tt.recipe, tt.args)
+			got, err := tt.recipe.BindArgs(tt.args)
 			if tt.wantErr {
 				if err == nil {
 					t.Fatal("expected error, got nil")
@@ -574,73 +642,194 @@ func TestMapArgs(t *testing.T) {
 	}
 }
 
-func TestParseArgs(t *testing.T) {
+func TestParseAttributes(t *testing.T) {
+	input := `# Build the binary
+[inputs("src/**/*.go", "go.mod")]
+[outputs("bin/app"), private]
+build:
+	go build -o bin/app
+`
+
+	jf, err := Parse(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	r := jf.Recipes[0]
+	assertEqual(t, "doc", r.Doc, "Build the binary")
+	assertEqual(t, "attribute count", len(r.Attributes), 3)
+	assertEqual(t, "third attribute", r.Attributes[2].Name, "private")
+	assertEqual(t, "inputs", strings.Join(r.Inputs, ","), "src/**/*.go,go.mod")
+	assertEqual(t, "outputs", strings.Join(r.Outputs, ","), "bin/app")
+	assertEqual(t, "incremental", r.Incremental(), true)
+}
+
+func TestParseAttributesOnlyApplyToNextItem(t *testing.T) {
+	input := `[private]
+x := "1"
+[no-cd]
+alias b := build
+[confirm]
+set quiet
+build:
+	true
+`
+
+	jf, err := Parse(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	assertEqual(t, "attributes", len(jf.Recipes[0].Attributes), 0)
+}
+
+func TestParseQuotedDefaults(t *testing.T) {
+	input := `greet name="hello world" sep=":" greeting='a # b': dep
+	echo {{greeting}} {{name}}
+
+dep:
+	true
+`
+
+	jf, err := Parse(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	r := jf.Recipes[0]
+	assertEqual(t, "params", len(r.Params), 3)
+	assertEqual(t, "name default", r.Params[0].Default, "hello world")
+	assertEqual(t, "sep default", r.Params[1].Default, ":")
+	assertEqual(t, "greeting default", r.Params[2].Default, "a # b")
+	assertEqual(t, "deps", strings.Join(r.Dependencies, ","), "dep")
+}
+
+func TestParseExpressionDefaults(t *testing.T) {
+	input := `build os=target tag=` + "`echo hi`" + ` *flags=os:
+	echo {{os}} {{tag}}
+
+target := "linux"
+`
+
+	jf, err := Parse(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	r := jf.Recipes[0]
+	assertEqual(t, "params", len(r.Params), 3)
+	assertEqual(t, "os name", r.Params[0].Name, "os")
+	assertEqual(t, "os default", r.Params[0].Default, "target")
+	assertEqual(t, "os kind", r.Params[0].DefaultKind, DefaultVariable)
+	assertEqual(t, "tag name", r.Params[1].Name, "tag")
+	assertEqual(t, "tag default", r.Params[1].Default, "echo hi")
+	assertEqual(t, "tag kind", r.Params[1].DefaultKind, DefaultBacktick)
+	assertEqual(t, "flags name", r.Params[2].Name, "flags")
+	assertEqual(t, "flags variadic", r.Params[2].Variadic, "*")
+	assertEqual(t, "source", FormatRecipe(&r), "build os=target tag=`echo hi` *flags=os:\n    echo {{os}} {{tag}}\n")
+
+	for _, tt := range []struct{ input, err string }{
+		{"a x=env_var(\"X\"):\n\techo\n", `line 1: recipe 'a': parameter 'x': default env_var("X") is not supported; use a string, a variable or a backtick`},
+		{"a x=nope:\n\techo\n", "line 1: recipe 'a': variable 'nope' not defined"},
+	} {
+		_, err := Parse(strings.NewReader(tt.input))
+		if err == nil {
+			t.Errorf("%q: expected error", tt.input)
+			continue
+		}
+		assertEqual(t, "error", err.Error(), tt.err)
+	}
+}
+
+func TestParseContinuations(t *testing.T) {
+	input := `sources := "src" / \
+    "main.go"
+flags := join(
+    "-v",   # verbose
+    "-race",
+)
+
+[group("ci"),
+ private]
+@test pkg="./..." \
+    *args: (build) \
+    lint
+	go test {{flags}} \
+	    {{pkg}}
+
+	echo {{args}}
+
+build:
+	true
+lint:
+	true
+`
+
+	jf, err := Parse(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	assertEqual(t, "sources", jf.Variables[0].Value, "src/main.go")
+	assertEqual(t, "flags", jf.Variables[1].Value, `join( "-v", "-race", )`)
+
+	r := jf.Recipes[0]
+	assertEqual(t, "name", r.Name, "test")
+	assertEqual(t, "silent", r.Silent, true)
+	assertEqual(t, "attributes", len(r.Attributes), 2)
+	assertEqual(t, "params", FormatParams(r.Params), "pkg=./... *args")
+	assertEqual(t, "deps", strings.Join(r.Dependencies, ","), "build,lint")
+	assertEqual(t, "lines", len(r.Lines), 2)
+	assertEqual(t, "line 0", r.Lines[0], "go test {{flags}} {{pkg}}")
+	assertEqual(t, "line 0 number", r.BodyLine(0), 13)
+	assertEqual(t, "line 1 number", r.BodyLine(1), 16)
+	assertEqual(t, "recipes", len(jf.Recipes), 3)
+}
+
+func TestParseSettings(t *testing.T) {
+	input := `set shell := ["bash", "-uc"]
+set quiet
+set fallback := true
+`
+
+	jf, err := Parse(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	assertEqual(t, "settings", len(jf.Settings), 3)
+	assertEqual(t, "shell", jf.Settings[0].Value, "bash -uc")
+	assertEqual(t, "quiet", jf.Settings[1].Value, "true")
+	assertEqual(t, "fallback", jf.Settings[2].Name, "fallback")
+	assertEqual(t, "fallback line", jf.Settings[2].Line, 3)
+	assertEqual(t, "recipes", len(jf.Recipes), 0)
+
+	fallback, ok := jf.Setting("fallback")
+	assertEqual(t, "fallback value", fallback, "true")
+	assertEqual(t, "fallback set", ok, true)
+	_, ok = jf.Setting("dotenv-load")
+	assertEqual(t, "unset", ok, false)
+}
+
+func TestParseErrors(t *testing.T) {
 	tests := []struct {
-		name   string
-		args   []string
-		want   options
+		input, want string
 	}{
-		{
-			name: "no args",
-			args: nil,
-			want: options{},
-		},
-		{
-			name: "list flag",
-			args: []string{"--list"},
-			want: options{list: true},
-		},
-		{
-			name: "short list flag",
-			args: []string{"-l"},
-			want: options{list: true},
-		},
-		{
-			name: "dump flag",
-			args: []string{"--dump"},
-			want: options{dump: true},
-		},
-		{
-			name: "target only",
-			args: []string{"build"},
-			want: options{target: "build", args: []string{}},
-		},
-		{
-			name: "target with args",
-			args: []string{"cli", "hello", "world"},
-			want: options{target: "cli", args: []string{"hello", "world"}},
-		},
-		{
-			name: "file flag then target",
-			args: []string{"-f", "myfile", "build"},
-			want: options{justfilePath: "myfile", target: "build", args: []string{}},
-		},
+		{"a: && b\n\ttrue\n", "line 1: recipe 'a': dependencies after && are not supported"},
+		{"import 'other.just'\n", "line 1: import is not supported"},
+		{"build: (compile \"release\")\n\ttrue\n", "line 1: recipe 'build': dependency arguments are not supported"},
+		{"mod a\nmod a 'b.just'\n", "line 2: module 'a' is declared twice"},
+		{"build:\n\ttrue\nnot valid\n", "line 3: expected ':', found newline"},
 	}
-
 	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			got := parseArgs(tt.args)
-			assertEqual(t, "justfilePath", got.justfilePath, tt.want.justfilePath)
-			assertEqual(t, "list", got.list, tt.want.list)
-			assertEqual(t, "dump", got.dump, tt.want.dump)
-			assertEqual(t, "dryRun", got.dryRun, tt.want.dryRun)
-			assertEqual(t, "showHelp", got.showHelp, tt.want.showHelp)
-			assertEqual(t, "showVersion", got.showVersion, tt.want.showVersion)
-			assertEqual(t, "target", got.target, tt.want.target)
-
-			if tt.want.args != nil {
-				if len(got.args) != len(tt.want.args) {
-					t.Fatalf("expected %d args, got %d", len(tt.want.args), len(got.args))
-				}
-				for i := range got.args {
-					assertEqual(t, "arg", got.args[i], tt.want.args[i])
-				}
-			}
-		})
+		_, err := Parse(strings.NewReader(tt.input))
+		if err == nil {
+			t.Errorf("%q: expected error", tt.input)
+			continue
+		}
+		assertEqual(t, tt.input, err.Error(), tt.want)
 	}
 }
 
-// findTestRecipe is a test helper that finds a recipe by name.
 func findTestRecipe(t *testing.T, jf *Justfile, name string) *Recipe {
 	t.Helper()
 	for i := range jf.Recipes {
@@ -653,6 +842,7 @@ func findTestRecipe(t *testing.T, jf *Justfile, name string) *Recipe {
 }
 
 // assertEqual is a generic test helper for comparing values.
+
 func assertEqual[T comparable](t *testing.T, label string, got, want T) {
 	t.Helper()
 	if got != want {
@@ -0,0 +1,238 @@
+package justfile
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var nameRe = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_-]*$`)
+
+// IsName reports whether s is a valid recipe, variable or parameter name.
+// An interpolation whose expression is a name is a plain reference rather
+// than an expression or function call.
+func IsName(s string) bool {
+	return nameRe.MatchString(s)
+}
+
+// FindRecipe returns the recipe with the given name, or nil.
+func (jf *Justfile) FindRecipe(name string) *Recipe {
+	for i := range jf.Recipes {
+		if jf.Recipes[i].Name == name {
+			return &jf.Recipes[i]
+		}
+	}
+	return nil
+}
+
+// FindVariable returns the variable with the given name, or nil.
+func (jf *Justfile) FindVariable(name string) *Variable {
+	for i := range jf.Variables {
+		if jf.Variables[i].Name == name {
+			return &jf.Variables[i]
+		}
+	}
+	return nil
+}
+
+// FindModule returns the submodule with the given name, or nil.
+func (jf *Justfile) FindModule(name string) *Module {
+	for i := range jf.Modules {
+		if jf.Modules[i].Name == name {
+			return &jf.Modules[i]
+		}
+	}
+	return nil
+}
+
+// Setting returns the value of the justfile's setting with the given
+// name, and whether it is set. A boolean setting set without a value, as
+// in `set fallback`, is "true". If a setting is set more than once, the
+// last value wins.
+func (jf *Justfile) Setting(name string) (string, bool) {
+	for i := len(jf.Settings) - 1; i >= 0; i-- {
+		if jf.Settings[i].Name == name {
+			return jf.Settings[i].Value, true
+		}
+	}
+	return "", false
+}
+
+// ResolveAlias resolves an alias to its target recipe name. Other names are
+// returned unchanged.
+func (jf *Justfile) ResolveAlias(name string) string {
+	for _, a := range jf.Aliases {
+		if a.Name == name {
+			return a.Target
+		}
+	}
+	return name
+}
+
+// BindArgs maps positional command-line arguments to the recipe's
+// parameters, returning NAME=value assignments. Variadic parameters take
+// the remaining arguments, joined with spaces. Parameters with defaults
+// that were given no argument are left out.
+func (r *Recipe) BindArgs(args []string) ([]string, error) {
+	var assignments []string
+
+	argIdx := 0
+	for _, p := range r.Params {
+		if p.Variadic != "" {
+			// Collect all remaining args.
+			if p.Variadic == "+" && argIdx >= len(args) {
+				return nil, fmt.Errorf("recipe '%s' requires at least one argument for '%s'", r.Name, p.Name)
+			}
+			if argIdx < len(args) {
+				val := strings.Join(args[argIdx:], " ")
+				assignments = append(assignments, fmt.Sprintf("%s=%s", p.Name, val))
+				argIdx = len(args)
+			}
+		} else if argIdx < len(args) {
+			assignments = append(assignments, fmt.Sprintf("%s=%s", p.Name, args[argIdx]))
+			argIdx++
+		} else if !p.HasDefault {
+			return nil, fmt.Errorf("recipe '%s' requires argument '%s'", r.Name, p.Name)
+		}
+	}
+
+	return assignments, nil
+}
+
+// HasListDefault reports whether the justfile's default recipe (its first)
+// is a `just --list` wrapper.
+func (jf *Justfile) HasListDefault() bool {
+	return len(jf.Recipes) > 0 && jf.Recipes[0].IsListDefault()
+}
+
+// IsListDefault returns true if the recipe is the default recipe that just calls `just --list`.
+func (r *Recipe) IsListDefault() bool {
+	if r.Name != "default" {
+		return false
+	}
+	if len(r.Lines) != 1 {
+		return false
+	}
+	trimmed := strings.TrimSpace(r.Lines[0])
+	return trimmed == "@just --list" || trimmed == "just --list"
+}
+
+// FormatParams formats a slice of Param into a display string.
+func FormatParams(params []Param) string {
+	var parts []string
+	for _, p := range params {
+		s := p.Name
+		switch p.Variadic {
+		case "*":
+			s = "*" + s
+		case "+":
+			s = "+" + s
+		}
+		switch {
+		case p.HasDefault && p.DefaultKind == DefaultValue:
+			s += "=" + p.Default
+		case p.HasDefault:
+			s += "=" + p.DefaultSource()
+		}
+		parts = append(parts, s)
+	}
+	return strings.Join(parts, " ")
+}
+
+// Groups returns the names given by the recipe's [group] attributes.
+func (r *Recipe) Groups() []string {
+	var groups []string
+	for _, a := range r.Attributes {
+		if a.Name == "group" && len(a.Args) > 0 {
+			groups = append(groups, a.Args[0])
+		}
+	}
+	return groups
+}
+
+// Usage returns an example invocation of the recipe by command: required
+// parameters in angle brackets, optional ones in square brackets, and
+// variadic ones followed by "...".
+func (r *Recipe) Usage(command string) string {
+	parts := []string{command, r.Name}
+	for _, p := range r.Params {
+		var s string
+		switch {
+		case p.Variadic == "+":
+			s = "<" + p.Name + ">..."
+		case p.Variadic == "*":
+			s = "[" + p.Name + "]..."
+		case !p.HasDefault:
+			s = "<" + p.Name + ">"
+		default:
+			s = "[" + p.Name + "]"
+		}
+		parts = append(parts, s)
+	}
+	return strings.Join(parts, " ")
+}
+
+// RecipeHelp returns the usage page of a recipe, as shown by
+// `jmake --help RECIPE`: how to invoke it with command, its full doc
+// comment, its parameters, dependencies, aliases and groups.
+func RecipeHelp(jf *Justfile, r *Recipe, command string) string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "Usage: %s\n", r.Usage(command))
+	if r.Doc != "" {
+		fmt.Fprintf(&b, "\n%s\n", r.Doc)
+	}
+
+	if len(r.Params) > 0 {
+		width := 0
+		for _, p := range r.Params {
+			width = max(width, len(p.Name))
+		}
+		b.WriteString("\nParameters:\n")
+		for _, p := range r.Params {
+			var desc string
+			switch {
+			case p.Variadic == "+":
+				desc = "one or more values"
+			case p.Variadic == "*":
+				desc = "zero or more values"
+			case !p.HasDefault:
+				desc = "required"
+			default:
+				desc = "default " + p.DefaultSource()
+			}
+			if p.Variadic != "" && p.HasDefault {
+				desc += ", default " + p.DefaultSource()
+			}
+			fmt.Fprintf(&b, "    %-*s  %s\n", width, p.Name, desc)
+		}
+	}
+
+	var aliases []string
+	for _, a := range jf.Aliases {
+		if a.Target == r.Name {
+			aliases = append(aliases, a.Name)
+		}
+	}
+
+	var details strings.Builder
+	if len(r.Dependencies) > 0 {
+		fmt.Fprintf(&details, "Dependencies: %s\n", strings.Join(r.Dependencies, " "))
+	}
+	if len(aliases) > 0 {
+		fmt.Fprintf(&details, "Aliases: %s\n", strings.Join(aliases, " "))
+	}
+	switch groups := r.Groups(); len(groups) {
+	case 0:
+	case 1:
+		fmt.Fprintf(&details, "Group: %s\n", groups[0])
+	default:
+		fmt.Fprintf(&details, "Groups: %s\n", strings.Join(groups, ", "))
+	}
+	if details.Len() > 0 {
+		b.WriteString("\n")
+		b.WriteString(details.String())
+	}
+
+	return b.String()
+}
@@ -1,35 +1,88 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
-	"os/exec"
 	"path/filepath"
+	"slices"
+	"strconv"
 	"strings"
+
+	"github.com/sammcj/jmake/justfile"
+	"github.com/sammcj/jmake/makegen"
+	"github.com/sammcj/jmake/runner"
 )
 
 var version = "dev"
 
 func main() {
-	if err := run(os.Args[1:]); err != nil {
+	err := run(os.Args[1:])
+	// os.Exit skips deferred calls, so temp files are removed explicitly.
+	runner.RemoveTempFiles()
+	if err == nil {
+		return
+	}
+
+	code := 1
+	var ec runner.ExitCoder
+	if errors.As(err, &ec) {
+		code = ec.ExitCode()
+	}
+	// A signal's exit status speaks for itself, as does a failure the
+	// recipe reported itself; anything else is reported, recipe failures in
+	// the same form just uses.
+	var (
+		se  *runner.SignalError
+		re  *runner.RecipeError
+		rep *runner.ReportedError
+	)
+	switch {
+	case errors.As(err, &se), errors.As(err, &rep):
+	case errors.As(err, &re):
+		fmt.Fprintf(os.Stderr, "error: %s\n", re)
+	default:
 		fmt.Fprintf(os.Stderr, "jmake: %s\n", err)
-		os.Exit(1)
 	}
+	os.Exit(code)
 }
 
 type options struct {
 	justfilePath string
+	global       bool   // --global-justfile
+	workDir      string // directory recipes run in; set by run
 	list         bool
+	listModule   string               // submodule to list, as name or a::b
+	listing      justfile.ListOptions // from --list-heading, --list-prefix, --unsorted and --list-submodules
+	show         string
 	dump         bool
+	settings     []setting // generator settings given as flags, in order
+	generate     makegen.Options
+	backend      string
+	convertPath  string
+	outputPath   string
+	checkPath    string
 	dryRun       bool
 	showHelp     bool
+	helpArg      bool // the only recipe argument is --help
 	showVersion  bool
+	force        bool
+	cacheStatus  bool
+	cacheClean   bool
+	timings      bool
+	traceFile    string
+	watch        bool
+	watchGlobs   []string
+	jobs         int
+	outputMode   string
+	colour       string
 	target       string
 	args         []string
 }
 
 func parseArgs(args []string) options {
-	var opts options
+	opts := options{listing: justfile.DefaultListOptions()}
 
 	i := 0
 	for i < len(args) {
@@ -40,23 +93,123 @@ func parseArgs(args []string) options {
 			if i < len(args) {
 				opts.justfilePath = args[i]
 			}
+		case a == "--global-justfile" || a == "-g":
+			opts.global = true
 		case a == "--list" || a == "-l":
 			opts.list = true
+			if i+1 < len(args) && !strings.HasPrefix(args[i+1], "-") {
+				i++
+				opts.listModule = args[i]
+			}
+		case a == "--list-submodules":
+			opts.listing.Submodules = true
+		case a == "--list-heading":
+			i++
+			if i < len(args) {
+				opts.listing.Heading = args[i]
+			}
+		case a == "--list-prefix":
+			i++
+			if i < len(args) {
+				opts.listing.Prefix = args[i]
+			}
+		case a == "--unsorted" || a == "-u":
+			opts.listing.Unsorted = true
+		case a == "--show" || a == "-s":
+			i++
+			if i < len(args) {
+				opts.show = args[i]
+			}
 		case a == "--dump" || a == "-d":
 			opts.dump = true
+		case a == "--output" || a == "-o":
+			i++
+			if i < len(args) {
+				opts.outputPath = args[i]
+			}
+		case a == "--check":
+			i++
+			if i < len(args) {
+				opts.checkPath = args[i]
+			}
+		case strings.HasPrefix(a, "--") && makegen.IsSetting(a[2:]):
+			name, value := a[2:], "true"
+			if !makegen.IsBoolSetting(name) {
+				i++
+				if i >= len(args) {
+					break
+				}
+				value = args[i]
+			}
+			opts.settings = append(opts.settings, setting{name, value})
+		case a == "--convert":
+			i++
+			if i < len(args) {
+				opts.convertPath = args[i]
+			}
+		case a == "--backend" || a == "--dump-format":
+			i++
+			if i < len(args) {
+				opts.backend = args[i]
+			}
 		case a == "--dry-run" || a == "-n":
 			opts.dryRun = true
 		case a == "--help" || a == "-h":
 			opts.showHelp = true
 		case a == "--version" || a == "-v":
 			opts.showVersion = true
+		case a == "--watch" || a == "-w":
+			opts.watch = true
+		case strings.HasPrefix(a, "--watch="):
+			opts.watch = true
+			for g := range strings.SplitSeq(strings.TrimPrefix(a, "--watch="), ",") {
+				if g = strings.TrimSpace(g); g != "" {
+					opts.watchGlobs = append(opts.watchGlobs, g)
+				}
+			}
+		case a == "--timings":
+			opts.timings = true
+		case a == "--trace":
+			i++
+			if i < len(args) {
+				opts.traceFile = args[i]
+			}
+		case a == "--force":
+			opts.force = true
+		case a == "--cache-status":
+			opts.cacheStatus = true
+		case a == "--cache-clean":
+			opts.cacheClean = true
+		case a == "--jobs" || a == "-j":
+			i++
+			if i < len(args) {
+				n, err := strconv.Atoi(args[i])
+				if err != nil || n < 1 {
+					fmt.Fprintf(os.Stderr, "jmake: invalid jobs value: %s\n", args[i])
+					os.Exit(1)
+				}
+				opts.jobs = n
+			}
+		case a == "--output-mode":
+			i++
+			if i < len(args) {
+				opts.outputMode = args[i]
+			}
+		case a == "--color" || a == "--colour":
+			i++
+			if i < len(args) {
+				opts.colour = args[i]
+			}
 		case strings.HasPrefix(a, "-"):
 			fmt.Fprintf(os.Stderr, "jmake: unknown flag: %s\n", a)
 			os.Exit(1)
 		default:
-			// First non-flag is the target, rest are recipe args.
+			// First non-flag is the target, rest are recipe args. A lone
+			// --help after it may ask for the recipe's help instead; see
+			// helpForRecipe.
 			opts.target = a
 			opts.args = args[i+1:]
+			opts.helpArg = len(opts.args) == 1 && opts.args[0] == "--help"
 			return opts
 		}
 		i++
@@ -64,10 +217,18 @@ func parseArgs(args []string) options {
 	return opts
 }
 
+// helpForRecipe reports whether `jmake RECIPE --help` asks for the
+// recipe's help. A recipe with a variadic parameter takes --help as an
+// argument instead, so that recipes wrapping other commands pass it on.
+func helpForRecipe(jf *justfile.Justfile, target string) bool {
+	r := jf.FindRecipe(jf.ResolveAlias(target))
+	return r == nil || !slices.ContainsFunc(r.Params, func(p justfile.Param) bool { return p.Variadic != "" })
+}
+
 func run(args []string) error {
 	opts := parseArgs(args)
 
-	if opts.showHelp {
+	if opts.showHelp && opts.target == "" {
 		printUsage()
 		return nil
 	}
@@ -76,104 +237,370 @@ func run(args []string) error {
 		return nil
 	}
 
+	switch opts.colour {
+	case "", "auto", "always", "never":
+	default:
+		return fmt.Errorf("invalid colour mode %q (want auto, always or never)", opts.colour)
+	}
+
+	// --convert: print a Makefile as a justfile, then exit.
+	if opts.convertPath != "" {
+		return convert(opts)
+	}
+
 	justfilePath := opts.justfilePath
-	if justfilePath == "" {
-		var err error
+	var err error
+	switch {
+	case opts.global && justfilePath != "":
+		return fmt.Errorf("--global-justfile and --file cannot be used together")
+	case opts.global:
+		justfilePath, err = findGlobalJustfile()
+	case justfilePath == "":
 		justfilePath, err = findJustfile()
-		if err != nil {
-			return err
-		}
+	}
+	if err != nil {
+		return err
 	}
 
-	f, err := os.Open(justfilePath)
+	jf, err := justfile.Load(justfilePath)
 	if err != nil {
-		return fmt.Errorf("opening justfile: %w", err)
+		return err
+	}
+	if opts.justfilePath == "" && !opts.global && opts.target != "" {
+		if justfilePath, jf, err = fallback(justfilePath, jf, opts.target); err != nil {
+			return err
+		}
 	}
-	defer f.Close()
 
-	jf, err := Parse(f)
+	// Recipes run in the justfile's directory, or, from the global
+	// justfile, in the one jmake was run from.
+	opts.workDir = filepath.Dir(justfilePath)
+	if opts.global {
+		if opts.workDir, err = os.Getwd(); err != nil {
+			return fmt.Errorf("getting working directory: %w", err)
+		}
+	}
+	opts.generate, err = generateOptions(filepath.Dir(justfilePath), opts.settings)
 	if err != nil {
 		return err
 	}
 
-	// Determine if the default recipe is a `just --list` wrapper.
-	hasListDefault := len(jf.Recipes) > 0 && isListDefault(&jf.Recipes[0])
+	if opts.helpArg && helpForRecipe(jf, opts.target) {
+		opts.showHelp, opts.args = true, nil
+	}
+
+	// --help RECIPE: print the recipe's usage page and exit.
+	if opts.showHelp {
+		recipe := jf.FindRecipe(jf.ResolveAlias(opts.target))
+		if recipe == nil {
+			return fmt.Errorf("unknown recipe: %s", opts.target)
+		}
+		fmt.Print(justfile.RecipeHelp(jf, recipe, "jmake"))
+		return nil
+	}
+
+	opts.listing.Colour = runner.UseColour(opts.colour, os.Stdout)
 
 	// --list: print recipes and exit.
 	if opts.list {
-		fmt.Print(ListRecipes(jf))
+		listed := jf
+		if opts.listModule != "" {
+			for name := range strings.SplitSeq(opts.listModule, "::") {
+				m := listed.FindModule(name)
+				if m == nil {
+					return fmt.Errorf("unknown module: %s", opts.listModule)
+				}
+				listed = m.Justfile
+			}
+		}
+		fmt.Print(justfile.ListRecipes(listed, opts.listing))
 		return nil
 	}
 
-	// --dump: generate and print Makefile, then exit.
+	// --show: print a recipe with its full doc comment and exit.
+	if opts.show != "" {
+		recipe := jf.FindRecipe(jf.ResolveAlias(opts.show))
+		if recipe == nil {
+			return fmt.Errorf("unknown recipe: %s", opts.show)
+		}
+		fmt.Print(justfile.FormatRecipe(recipe))
+		return nil
+	}
+
+	if (opts.outputPath != "" || opts.checkPath != "") && !opts.dump {
+		return fmt.Errorf("--output and --check require --dump")
+	}
+
+	// --dump: convert the justfile with the chosen backend, then exit.
 	if opts.dump {
-		fmt.Print(Generate(jf, hasListDefault))
+		backend, err := runner.LookupBackend(opts.backend)
+		if err != nil {
+			return err
+		}
+		if backend.Ext() == "" {
+			return fmt.Errorf("the %s backend runs recipes directly and has nothing to dump", backend.Name())
+		}
+		gen := backend.Generate(jf, opts.generate)
+		for _, w := range gen.Warnings {
+			loc := justfilePath
+			if w.Line > 0 {
+				loc = fmt.Sprintf("%s:%d", justfilePath, w.Line)
+			}
+			fmt.Fprintf(os.Stderr, "jmake: warning: %s: %s\n", loc, w.Message)
+		}
+		return dump(gen.Content, opts)
+	}
+
+	dir := opts.workDir
+	cache, err := runner.LoadCache(dir)
+	if err != nil {
+		return err
+	}
+
+	// --cache-clean: forget all recorded fingerprints.
+	if opts.cacheClean {
+		return cache.Clean()
+	}
+
+	// --cache-status: report what would run for the target, or every recipe.
+	if opts.cacheStatus {
+		var vars []string
+		target := jf.ResolveAlias(opts.target)
+		if target != "" {
+			recipe := jf.FindRecipe(target)
+			if recipe == nil {
+				return fmt.Errorf("unknown recipe: %s", target)
+			}
+			if vars, err = recipe.BindArgs(opts.args); err != nil {
+				return err
+			}
+		}
+		return runner.WriteCacheStatus(os.Stdout, jf, dir, cache, target, vars, opts.force)
+	}
+
+	if _, err := runner.ParseOutputMode(opts.outputMode); err != nil {
+		return err
+	}
+
+	// --watch: rerun the recipe whenever files change.
+	if opts.watch {
+		return watch(justfilePath, opts)
+	}
+
+	return execute(context.Background(), jf, justfilePath, opts)
+}
+
+// dump writes generated content to stdout or the --output path, or with
+// --check compares it against the file at the --check path, printing a
+// unified diff and failing if they differ.
+func dump(content string, opts options) error {
+	switch {
+	case opts.checkPath != "":
+		existing, err := os.ReadFile(opts.checkPath)
+		if err != nil {
+			return fmt.Errorf("reading %s: %w", opts.checkPath, err)
+		}
+		diff := unifiedDiff(opts.checkPath, opts.checkPath+" (generated)", string(existing), content)
+		if diff == "" {
+			return nil
+		}
+		fmt.Print(diff)
+		return fmt.Errorf("%s is out of date with the justfile; regenerate it with --dump -o %s", opts.checkPath, opts.checkPath)
+	case opts.outputPath != "":
+		perm := os.FileMode(0o644)
+		if opts.backend == "sh" {
+			perm = 0o755
+		}
+		if err := os.WriteFile(opts.outputPath, []byte(content), perm); err != nil {
+			return fmt.Errorf("writing %s: %w", opts.outputPath, err)
+		}
+		return nil
+	default:
+		fmt.Print(content)
 		return nil
 	}
+}
 
+// convert translates the Makefile at the --convert path into a justfile,
+// written to stdout or the --output path. Constructs that could not be
+// converted are reported on stderr.
+func convert(opts options) error {
+	if opts.checkPath != "" {
+		return fmt.Errorf("--check cannot be used with --convert")
+	}
+	f, err := os.Open(opts.convertPath)
+	if err != nil {
+		return fmt.Errorf("opening Makefile: %w", err)
+	}
+	defer f.Close()
+
+	jf, warnings, err := makegen.Convert(f)
+	if err != nil {
+		return err
+	}
+	for _, w := range warnings {
+		fmt.Fprintf(os.Stderr, "jmake: warning: %s:%d: %s\n", opts.convertPath, w.Line, w.Message)
+	}
+	return dump(justfile.Format(jf), opts)
+}
+
+// execute runs the requested recipe (or the default one) from a parsed
+// justfile, generating a temporary Makefile for make to work from.
+// Cancelling ctx interrupts any running recipes.
+func execute(ctx context.Context, jf *justfile.Justfile, justfilePath string, opts options) error {
 	// No target specified: if default is list, show list; otherwise use default.
-	if opts.target == "" {
-		if hasListDefault {
-			fmt.Print(ListRecipes(jf))
+	target := opts.target
+	if target == "" {
+		if jf.HasListDefault() {
+			fmt.Print(justfile.ListRecipes(jf, opts.listing))
 			return nil
 		}
 		if len(jf.Recipes) > 0 {
-			opts.target = jf.Recipes[0].Name
+			target = jf.Recipes[0].Name
 		} else {
 			return fmt.Errorf("no recipes found in justfile")
 		}
 	}
 
 	// Resolve aliases.
-	opts.target = resolveAlias(jf, opts.target)
+	target = jf.ResolveAlias(target)
 
 	// Find the target recipe.
-	recipe := findRecipe(jf, opts.target)
+	recipe := jf.FindRecipe(target)
 	if recipe == nil {
-		return fmt.Errorf("unknown recipe: %s", opts.target)
+		return fmt.Errorf("unknown recipe: %s", target)
 	}
 
-	// Build make variable assignments from positional args.
-	makeVars, err := mapArgs(recipe, opts.args)
-	if err != nil {
+	// Check the arguments before anything runs.
+	if _, err := recipe.BindArgs(opts.args); err != nil {
 		return err
 	}
 
-	// Generate Makefile.
-	content := Generate(jf, hasListDefault)
+	backend, err := runner.LookupBackend(opts.backend)
+	if err != nil {
+		return err
+	}
 
-	// Write to temp file and execute make.
-	tmpFile, err := os.CreateTemp("", "jmake-*.mk")
+	dir := opts.workDir
+	cache, err := runner.LoadCache(dir)
 	if err != nil {
-		return fmt.Errorf("creating temp file: %w", err)
+		return err
 	}
-	tmpPath := tmpFile.Name()
-	defer os.Remove(tmpPath)
 
-	if _, err := tmpFile.WriteString(content); err != nil {
-		tmpFile.Close()
-		return fmt.Errorf("writing temp makefile: %w", err)
+	// Run the recipe graph from the working directory, handing the backend
+	// one recipe at a time.
+	mode, _ := runner.ParseOutputMode(opts.outputMode)
+	output := runner.NewOutput(mode, os.Stdout, os.Stderr, runner.UseColour(opts.colour, os.Stdout))
+	ex := runner.NewExecutor(jf, backend, dir, opts.jobs, output)
+	ex.DryRun = opts.dryRun
+	ex.Cache = cache
+	ex.Force = opts.force
+	ex.JustfilePath = justfilePath
+	if opts.timings || opts.traceFile != "" {
+		ex.Timings = runner.NewTimings()
 	}
-	tmpFile.Close()
 
-	// Build make command.
-	makeArgs := []string{"--no-print-directory", "-f", tmpPath, opts.target}
-	makeArgs = append(makeArgs, makeVars...)
+	// Recipes get process groups of their own, so that signals reach
+	// everything they started, unless they may need to read from the
+	// terminal: only the foreground group can do that.
+	ex.ProcessGroups = opts.watch || !runner.IsTerminal(os.Stdin)
+
+	// Watch mode stops runs by cancelling ctx; otherwise signals sent to
+	// jmake are forwarded to the recipes. Installing the handler before the
+	// temp file exists means a signal can no longer leave it behind.
+	if !opts.watch {
+		stop := runner.ForwardSignals(ex)
+		defer stop()
+	}
 
-	if opts.dryRun {
-		fmt.Printf("make %s\n", strings.Join(makeArgs, " "))
-		return nil
+	// Style settings are for Makefiles users keep; the one run here only
+	// takes those that change how recipes run.
+	if err := ex.Load(backend.Generate(jf, opts.generate.Runtime())); err != nil {
+		return err
 	}
+	defer ex.Close()
 
-	cmd := exec.Command("make", makeArgs...)
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-	cmd.Stdin = os.Stdin
+	err = ex.Run(ctx, target, opts.args)
 
-	// Set working directory to the justfile's directory.
-	cmd.Dir = filepath.Dir(justfilePath)
+	// Timings are reported even when a recipe failed; that is often when
+	// they are most useful.
+	if ex.Timings != nil && !opts.dryRun {
+		if opts.timings {
+			fmt.Fprintln(os.Stderr)
+			if werr := ex.Timings.WriteSummary(os.Stderr); werr != nil && err == nil {
+				err = werr
+			}
+		}
+		if opts.traceFile != "" {
+			if werr := writeTraceFile(opts.traceFile, ex.Timings); werr != nil && err == nil {
+				err = werr
+			}
+		}
+	}
+	return err
+}
+
+// writeTraceFile writes the run's Chrome trace-event JSON to path.
+func writeTraceFile(path string, t *runner.Timings) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("creating trace file: %w", err)
+	}
+	if err := t.WriteTrace(f); err != nil {
+		f.Close()
+		return fmt.Errorf("writing trace file: %w", err)
+	}
+	return f.Close()
+}
 
-	return cmd.Run()
+// findGlobalJustfile returns the path of the user's global justfile: as in
+// just, the first of $XDG_CONFIG_HOME/just/justfile,
+// ~/.config/just/justfile and ~/.justfile that exists.
+func findGlobalJustfile() (string, error) {
+	var candidates []string
+	if xdg := os.Getenv("XDG_CONFIG_HOME"); xdg != "" {
+		candidates = append(candidates, filepath.Join(xdg, "just", "justfile"))
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		candidates = append(candidates,
+			filepath.Join(home, ".config", "just", "justfile"),
+			filepath.Join(home, ".justfile"))
+	}
+
+	for _, path := range candidates {
+		if fi, err := os.Stat(path); err == nil && !fi.IsDir() {
+			return path, nil
+		}
+	}
+	return "", fmt.Errorf("no global justfile found")
+}
+
+// fallback finds the justfile to run target from when jf, the justfile
+// found at path, has no such recipe. As in just, if jf sets fallback the
+// justfiles in the directories above are tried in turn, for as long as
+// each sets fallback too. The recipe runs in the directory of the
+// justfile it is found in. If none has it, path and jf are returned
+// unchanged.
+func fallback(path string, jf *justfile.Justfile, target string) (string, *justfile.Justfile, error) {
+	p, f := path, jf
+	for f.FindRecipe(f.ResolveAlias(target)) == nil {
+		if v, _ := f.Setting("fallback"); v != "true" {
+			return path, jf, nil
+		}
+		dir := filepath.Dir(filepath.Dir(p))
+		if dir == filepath.Dir(p) {
+			return path, jf, nil
+		}
+		next, err := findJustfileFrom(dir)
+		if err != nil {
+			return path, jf, nil
+		}
+		if f, err = justfile.Load(next); err != nil {
+			return "", nil, err
+		}
+		p = next
+	}
+	return p, f, nil
 }
 
 // findJustfile searches for a justfile starting from cwd and walking up.
@@ -182,13 +609,20 @@ func findJustfile() (string, error) {
 	if err != nil {
 		return "", fmt.Errorf("getting working directory: %w", err)
 	}
+	return findJustfileFrom(dir)
+}
 
+// findJustfileFrom searches for a justfile starting from dir and walking
+// up.
+func findJustfileFrom(dir string) (string, error) {
 	names := []string{"justfile", "Justfile", ".justfile"}
 
 	for {
 		for _, name := range names {
+			// A directory of the same name, such as a Go package called
+			// justfile, is not a justfile.
 			path := filepath.Join(dir, name)
-			if _, err := os.Stat(path); err == nil {
+			if fi, err := os.Stat(path); err == nil && fi.Mode().IsRegular() {
 				return path, nil
 			}
 		}
@@ -203,65 +637,71 @@ func findJustfile() (string, error) {
 	return "", fmt.Errorf("no justfile found")
 }
 
-// resolveAlias resolves an alias to its target recipe name.
-func resolveAlias(jf *Justfile, name string) string {
-	for _, a := range jf.Aliases {
-		if a.Name == name {
-			return a.Target
-		}
-	}
-	return name
-}
-
-// findRecipe returns the recipe with the given name, or nil.
-func findRecipe(jf *Justfile, name string) *Recipe {
-	for i := range jf.Recipes {
-		if jf.Recipes[i].Name == name {
-			return &jf.Recipes[i]
-		}
-	}
-	return nil
-}
-
-// mapArgs maps positional CLI args to recipe parameters, returning Make variable assignments.
-func mapArgs(r *Recipe, args []string) ([]string, error) {
-	var assignments []string
-
-	argIdx := 0
-	for _, p := range r.Params {
-		if p.Variadic != "" {
-			// Collect all remaining args.
-			if p.Variadic == "+" && argIdx >= len(args) {
-				return nil, fmt.Errorf("recipe '%s' requires at least one argument for '%s'", r.Name, p.Name)
-			}
-			if argIdx < len(args) {
-				val := strings.Join(args[argIdx:], " ")
-				assignments = append(assignments, fmt.Sprintf("%s=%s", p.Name, val))
-				argIdx = len(args)
-			}
-		} else if argIdx < len(args) {
-			assignments = append(assignments, fmt.Sprintf("%s=%s", p.Name, args[argIdx]))
-			argIdx++
-		} else if p.Default == "" {
-			return nil, fmt.Errorf("recipe '%s' requires argument '%s'", r.Name, p.Name)
-		}
-	}
-
-	return assignments, nil
-}
-
 func printUsage() {
 	fmt.Print(`jmake - run justfile recipes via make
 
 Usage:
   jmake [flags] [recipe] [args...]
+  jmake --help RECIPE | jmake RECIPE --help
+
+RECIPE --help passes --help to RECIPE instead when RECIPE takes variadic
+arguments (*ARGS or +ARGS), so that recipes wrapping other commands work.
 
 Flags:
-  -l, --list       List available recipes
+  -l, --list [MODULE]
+                   List available recipes, or those of submodule MODULE (a::b for nested ones)
+      --list-submodules
+                   With --list, also list the recipes of each submodule
+      --list-heading TEXT
+                   Print TEXT before the list (default "Available recipes:\n")
+      --list-prefix TEXT
+                   Print TEXT before each listed recipe (default four spaces)
+  -u, --unsorted   List recipes and groups in justfile order rather than by name
+  -s, --show RECIPE
+                   Print a recipe with its full doc comment
   -d, --dump       Print generated Makefile to stdout
+      --annotate   With --dump, mark each recipe with its justfile line
+  -o, --output PATH
+                   With --dump, write to PATH instead of stdout
+      --check PATH With --dump, fail with a diff if PATH is not up to date
+      --positional-args
+                   With --dump, also accept recipe arguments as make NAME ARGS="a b"
+      --make-shell SHELL
+                   SHELL for the generated Makefile, "" for make's default (/bin/bash)
+      --make-phony POLICY
+                   Declare targets .PHONY: all, per-target or none (default all)
+      --make-help-target NAME
+                   Name of the target replacing a just --list default, "" to keep it
+      --make-header TEXT
+                   Comment at the top of the Makefile (\n for new lines), "" for none
+      --make-flavour FLAVOUR
+                   Target gnu or posix make (default gnu)
+      --make-oneshell, --make-delete-on-error, --make-silent
+                   Add .ONESHELL, .DELETE_ON_ERROR or .SILENT to the Makefile
+      --convert PATH
+                   Print the Makefile at PATH as a justfile (or write it with -o)
   -f, --file PATH  Specify justfile path
-  -n, --dry-run    Show make command without executing
-  -h, --help       Show this help
+  -g, --global-justfile
+                   Use the global justfile ($XDG_CONFIG_HOME/just/justfile,
+                   ~/.config/just/justfile or ~/.justfile), running recipes here
+  -n, --dry-run    Show make commands without executing
+      --backend NAME
+                   Run (or --dump) with make, native, sh or taskfile (default make)
+  -j, --jobs N     Run up to N recipes concurrently (default 1)
+      --output-mode MODE
+                   Output for concurrent recipes: interleaved, prefixed or grouped
+      --color WHEN Colour recipe prefixes and --list: auto, always or never
+  -w, --watch[=GLOBS]
+                   Rerun the recipe when files change (GLOBS is comma-separated)
+      --timings    Print a per-recipe timing summary when the run finishes
+      --trace FILE Write a Chrome trace-event JSON file of the run
+      --force      Run recipes even if their cached fingerprint is unchanged
+      --cache-status
+                   Show whether each recipe would run, and why
+      --cache-clean
+                   Remove recorded recipe fingerprints
+  -h, --help [RECIPE]
+                   Show this help, or the parameters and doc of RECIPE
   -v, --version    Show version
 `)
 }
@@ -0,0 +1,230 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sammcj/jmake/justfile"
+)
+
+func TestParseArgs(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want options
+	}{
+		{
+			name: "no args",
+			args: nil,
+			want: options{},
+		},
+		{
+			name: "list flag",
+			args: []string{"--list"},
+			want: options{list: true},
+		},
+		{
+			name: "short list flag",
+			args: []string{"-l"},
+			want: options{list: true},
+		},
+		{
+			name: "list module",
+			args: []string{"--list", "frontend::ui"},
+			want: options{list: true, listModule: "frontend::ui"},
+		},
+		{
+			name: "global justfile",
+			args: []string{"-g", "hello"},
+			want: options{global: true, target: "hello", args: []string{}},
+		},
+		{
+			name: "show flag",
+			args: []string{"--show", "build"},
+			want: options{show: "build"},
+		},
+		{
+			name: "help for recipe",
+			args: []string{"--help", "build"},
+			want: options{showHelp: true, target: "build", args: []string{}},
+		},
+		{
+			name: "recipe then help",
+			args: []string{"build", "--help"},
+			want: options{helpArg: true, target: "build", args: []string{"--help"}},
+		},
+		{
+			name: "help among recipe args",
+			args: []string{"cli", "--help", "x"},
+			want: options{target: "cli", args: []string{"--help", "x"}},
+		},
+		{
+			name: "dump flag",
+			args: []string{"--dump"},
+			want: options{dump: true},
+		},
+		{
+			name: "target only",
+			args: []string{"build"},
+			want: options{target: "build", args: []string{}},
+		},
+		{
+			name: "target with args",
+			args: []string{"cli", "hello", "world"},
+			want: options{target: "cli", args: []string{"hello", "world"}},
+		},
+		{
+			name: "file flag then target",
+			args: []string{"-f", "myfile", "build"},
+			want: options{justfilePath: "myfile", target: "build", args: []string{}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parseArgs(tt.args)
+			assertEqual(t, "justfilePath", got.justfilePath, tt.want.justfilePath)
+			assertEqual(t, "global", got.global, tt.want.global)
+			assertEqual(t, "list", got.list, tt.want.list)
+			assertEqual(t, "listModule", got.listModule, tt.want.listModule)
+			assertEqual(t, "show", got.show, tt.want.show)
+			assertEqual(t, "dump", got.dump, tt.want.dump)
+			assertEqual(t, "dryRun", got.dryRun, tt.want.dryRun)
+			assertEqual(t, "showHelp", got.showHelp, tt.want.showHelp)
+			assertEqual(t, "helpArg", got.helpArg, tt.want.helpArg)
+			assertEqual(t, "showVersion", got.showVersion, tt.want.showVersion)
+			assertEqual(t, "target", got.target, tt.want.target)
+
+			if tt.want.args != nil {
+				if len(got.args) != len(tt.want.args) {
+					t.Fatalf("expected %d args, got %d", len(tt.want.args), len(got.args))
+				}
+				for i := range got.args {
+					assertEqual(t, "arg", got.args[i], tt.want.args[i])
+				}
+			}
+		})
+	}
+}
+
+func TestHelpForRecipe(t *testing.T) {
+	jf, err := justfile.Parse(strings.NewReader(`alias c := cli
+
+build target:
+	go build {{target}}
+
+cli *ARGS:
+	go run . {{ARGS}}
+`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	assertEqual(t, "plain params", helpForRecipe(jf, "build"), true)
+	assertEqual(t, "variadic", helpForRecipe(jf, "cli"), false)
+	assertEqual(t, "alias of variadic", helpForRecipe(jf, "c"), false)
+	assertEqual(t, "unknown recipe", helpForRecipe(jf, "nope"), true)
+}
+
+func TestFindJustfileSkipsDirectories(t *testing.T) {
+	dir := t.TempDir()
+	want := filepath.Join(dir, "justfile")
+	if err := os.WriteFile(want, []byte("build:\n\ttrue\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	// A package directory named justfile, as in jmake's own repository.
+	start := filepath.Join(dir, "repo")
+	if err := os.MkdirAll(filepath.Join(start, "justfile"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := findJustfileFrom(start)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	assertEqual(t, "path", got, want)
+}
+
+func TestFallback(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		t.Helper()
+		p := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return p
+	}
+	root := write("justfile", "deploy:\n\ttrue\n")
+	mid := write("packages/justfile", "set fallback\nlint:\n\ttrue\n")
+	leaf := write("packages/api/justfile", "set fallback\ntest:\n\ttrue\n")
+	closed := write("other/justfile", "build:\n\ttrue\n")
+
+	tests := []struct {
+		name, path, target, want string
+	}{
+		{"own recipe", leaf, "test", leaf},
+		{"parent", leaf, "lint", mid},
+		{"grandparent", leaf, "deploy", root},
+		{"unknown", leaf, "nope", leaf},
+		{"fallback not set", closed, "deploy", closed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			jf, err := justfile.Load(tt.path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, gotJf, err := fallback(tt.path, jf, tt.target)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			assertEqual(t, "path", got, tt.want)
+			if tt.want != tt.path {
+				assertEqual(t, "recipe found", gotJf.FindRecipe(tt.target) != nil, true)
+			}
+		})
+	}
+}
+
+func TestFindGlobalJustfile(t *testing.T) {
+	dir := t.TempDir()
+	home := filepath.Join(dir, "home")
+	xdg := filepath.Join(dir, "xdg")
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", xdg)
+
+	if _, err := findGlobalJustfile(); err == nil {
+		t.Fatal("expected error with no global justfile")
+	}
+
+	// Each file found takes precedence over those written before it.
+	for _, path := range []string{
+		filepath.Join(home, ".justfile"),
+		filepath.Join(home, ".config", "just", "justfile"),
+		filepath.Join(xdg, "just", "justfile"),
+	} {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("hello:\n\ttrue\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		got, err := findGlobalJustfile()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		assertEqual(t, "path", got, path)
+	}
+}
+
+func assertEqual[T comparable](t *testing.T, label string, got, want T) {
+	t.Helper()
+	if got != want {
+		t.Errorf("%s: got %v, want %v", label, got, want)
+	}
+}
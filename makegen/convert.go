@@ -0,0 +1,514 @@
+package makegen
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/sammcj/jmake/justfile"
+)
+
+var (
+	// Variable assignment in any flavour: NAME = v, NAME := v, NAME ::= v,
+	// NAME ?= v, NAME += v and NAME != cmd, optionally exported.
+	makeAssignRe = regexp.MustCompile(`^(export\s+|override\s+)?([A-Za-z_][A-Za-z0-9_.-]*)\s*(::=|:=|\?=|\+=|!=|=)\s*(.*)$`)
+
+	// Rule: targets: prerequisites, or targets:: prerequisites.
+	makeRuleRe = regexp.MustCompile(`^([^:=#]+?)\s*(::?)(.*)$`)
+
+	// A "## doc" comment, on its own line or after a rule.
+	makeDocRe = regexp.MustCompile(`^##+\s*(.*)$`)
+
+	// .DEFAULT_GOAL := target
+	makeGoalRe = regexp.MustCompile(`^\.DEFAULT_GOAL\s*:{0,2}=\s*(\S+)\s*$`)
+)
+
+// makeConverter holds the state of a Makefile being converted.
+type makeConverter struct {
+	jf       *justfile.Justfile
+	warnings []Warning
+
+	vars     map[string]int  // index into jf.Variables
+	recipes  map[string]int  // index into jf.Recipes
+	phony    map[string]bool // targets listed in .PHONY
+	exports  map[string]bool // names exported before they were assigned
+	ruleDeps map[string][]string
+	goal     string // .DEFAULT_GOAL
+}
+
+// Convert parses a subset of GNU make into a Justfile:
+//   - variable assignments of every flavour, with references to earlier
+//     variables expanded and $(shell ...) values turned into backticks
+//   - rules, whose targets become recipes and prerequisites dependencies
+//   - .PHONY and .DEFAULT_GOAL
+//   - "## doc" comments above a rule or at the end of its line
+//
+// In recipe lines, $(VAR) becomes {{VAR}} for Makefile variables and
+// ${VAR} for anything else, which make would take from the environment.
+//
+// Constructs that have no justfile equivalent, such as pattern rules,
+// conditionals and most make functions, are reported as warnings and
+// skipped or passed through unchanged.
+func Convert(r io.Reader) (*justfile.Justfile, []Warning, error) {
+	c := &makeConverter{
+		jf:       &justfile.Justfile{},
+		vars:     make(map[string]int),
+		recipes:  make(map[string]int),
+		phony:    make(map[string]bool),
+		exports:  make(map[string]bool),
+		ruleDeps: make(map[string][]string),
+	}
+
+	var (
+		scanner  = bufio.NewScanner(r)
+		lineNum  int
+		inRule   bool  // recipe lines follow
+		current  []int // recipes receiving recipe lines
+		doc      string
+		skipTo   string // directive ending a skipped block
+		ifDepth  int    // nesting of conditionals being skipped within
+		inBranch int    // conditionals whose first branch is being kept
+	)
+
+	for scanner.Scan() {
+		lineNum++
+		line := scanner.Text()
+		start := lineNum
+
+		// Recipe lines.
+		if inRule && strings.HasPrefix(line, "\t") && skipTo == "" {
+			body := strings.TrimPrefix(line, "\t")
+			for strings.HasSuffix(body, "\\") && scanner.Scan() {
+				lineNum++
+				c.addRecipeLine(current, body, start)
+				body = strings.TrimPrefix(scanner.Text(), "\t")
+			}
+			c.addRecipeLine(current, body, start)
+			continue
+		}
+
+		// Join continuation lines.
+		for strings.HasSuffix(line, "\\") && scanner.Scan() {
+			lineNum++
+			line = strings.TrimSuffix(line, "\\") + " " + strings.TrimSpace(scanner.Text())
+		}
+		trimmed := strings.TrimSpace(line)
+		directive, _, _ := strings.Cut(trimmed, " ")
+
+		// Skipped blocks: define ... endef, and the else branches of
+		// conditionals.
+		if skipTo != "" {
+			switch {
+			case skipTo == "endef" && directive == "endef":
+				skipTo = ""
+			case skipTo == "endif" && isConditional(directive):
+				ifDepth++
+			case skipTo == "endif" && directive == "endif":
+				if ifDepth == 0 {
+					skipTo = ""
+					inBranch--
+				} else {
+					ifDepth--
+				}
+			}
+			continue
+		}
+
+		if trimmed == "" {
+			doc = ""
+			continue
+		}
+		if m := makeDocRe.FindStringSubmatch(trimmed); m != nil {
+			doc = m[1]
+			continue
+		}
+		if strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		inRule, current = false, nil
+
+		switch {
+		case isConditional(directive):
+			c.warn(start, "conditional %q is not supported; only its first branch is converted", trimmed)
+			inBranch++
+			doc = ""
+			continue
+		case directive == "else":
+			if inBranch > 0 {
+				skipTo = "endif"
+			}
+			continue
+		case directive == "endif":
+			if inBranch > 0 {
+				inBranch--
+			}
+			continue
+		case directive == "define":
+			c.warn(start, "multi-line variable %q is not supported", strings.TrimSpace(strings.TrimPrefix(trimmed, "define")))
+			skipTo = "endef"
+			continue
+		case directive == "include" || directive == "-include" || directive == "sinclude":
+			c.warn(start, "%s is not supported; included files are not converted", directive)
+			continue
+		case directive == "vpath" || directive == "unexport":
+			c.warn(start, "%s is not supported", directive)
+			continue
+		case directive == "export" && !strings.ContainsAny(trimmed, "=:"):
+			for _, name := range strings.Fields(trimmed)[1:] {
+				c.export(name)
+			}
+			continue
+		}
+
+		if m := makeGoalRe.FindStringSubmatch(trimmed); m != nil {
+			c.goal = m[1]
+			continue
+		}
+
+		if m := makeAssignRe.FindStringSubmatch(trimmed); m != nil {
+			c.assign(start, strings.TrimSpace(m[1]), m[2], m[3], strings.TrimSpace(m[4]))
+			doc = ""
+			continue
+		}
+
+		if m := makeRuleRe.FindStringSubmatch(trimmed); m != nil {
+			inRule, current = true, c.rule(start, m[1], m[2], m[3], doc)
+			// A doc comment may sit above a rule's .PHONY declaration.
+			if strings.TrimSpace(m[1]) != ".PHONY" {
+				doc = ""
+			}
+			continue
+		}
+
+		c.warn(start, "unrecognised line %q", trimmed)
+		doc = ""
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, nil, fmt.Errorf("reading Makefile: %w", err)
+	}
+
+	c.finish()
+	slices.SortStableFunc(c.warnings, func(a, b Warning) int { return a.Line - b.Line })
+	return c.jf, c.warnings, nil
+}
+
+func isConditional(directive string) bool {
+	switch directive {
+	case "ifeq", "ifneq", "ifdef", "ifndef":
+		return true
+	}
+	return false
+}
+
+func (c *makeConverter) warn(line int, format string, args ...any) {
+	c.warnings = append(c.warnings, Warning{line, fmt.Sprintf(format, args...)})
+}
+
+// export marks a variable as exported, now if it is defined and otherwise
+// when it is.
+func (c *makeConverter) export(name string) {
+	if i, ok := c.vars[name]; ok {
+		c.jf.Variables[i].Export = true
+		return
+	}
+	c.exports[name] = true
+}
+
+// assign records a variable assignment. Every flavour becomes a just
+// assignment, which like := is evaluated once, in order.
+func (c *makeConverter) assign(line int, modifier, name, op, value string) {
+	if !justfile.IsName(name) {
+		c.warn(line, "variable name %q is not valid in a justfile", name)
+		return
+	}
+	if modifier == "override" {
+		c.warn(line, "override has no effect in a justfile")
+	}
+
+	v := justfile.Variable{Name: name, Line: line, Export: modifier == "export" || c.exports[name]}
+	switch {
+	case op == "!=":
+		v.Value, v.Backtick = c.expandValue(line, name, value), true
+	case strings.HasPrefix(value, "$(shell ") && strings.HasSuffix(value, ")") && matchingParen(value, 1) == len(value)-1:
+		v.Value, v.Backtick = c.expandValue(line, name, value[len("$(shell "):len(value)-1]), true
+	default:
+		v.Value = c.expandValue(line, name, value)
+	}
+
+	i, defined := c.vars[name]
+	switch op {
+	case "?=":
+		if defined {
+			return
+		}
+		c.warn(line, "variable %s: ?= cannot be overridden from the environment in a justfile", name)
+	case "+=":
+		if defined {
+			prev := &c.jf.Variables[i]
+			if prev.Backtick || v.Backtick {
+				c.warn(line, "variable %s: cannot append to or from a command's output", name)
+				return
+			}
+			prev.Value = strings.TrimSpace(prev.Value + " " + v.Value)
+			prev.Export = prev.Export || v.Export
+			return
+		}
+	}
+
+	if defined {
+		c.jf.Variables[i] = v
+		return
+	}
+	c.vars[name] = len(c.jf.Variables)
+	c.jf.Variables = append(c.jf.Variables, v)
+}
+
+// expandValue expands references to earlier plain variables in a variable's
+// value, since a justfile string cannot refer to other variables.
+func (c *makeConverter) expandValue(line int, name, value string) string {
+	return rewriteMakeRefs(value, func(ref string) string {
+		if ref == "$" {
+			return "$"
+		}
+		if i, ok := c.vars[ref]; ok && !c.jf.Variables[i].Backtick {
+			return c.jf.Variables[i].Value
+		}
+		c.warn(line, "variable %s: reference %s cannot be expanded", name, makeRef(ref))
+		return makeRef(ref)
+	})
+}
+
+// expandNames expands variable references in the targets or prerequisites
+// of a rule. References that cannot be expanded are left in place, and the
+// names containing them are reported as file targets.
+func (c *makeConverter) expandNames(s string) string {
+	return rewriteMakeRefs(s, func(ref string) string {
+		if i, ok := c.vars[ref]; ok && !c.jf.Variables[i].Backtick {
+			return c.jf.Variables[i].Value
+		}
+		return makeRef(ref)
+	})
+}
+
+// rule records a rule, creating or extending a recipe for each target, and
+// returns the recipes that the following recipe lines belong to.
+func (c *makeConverter) rule(line int, targets, colons, rest, doc string) []int {
+	if prereqs, comment, ok := strings.Cut(rest, "##"); ok {
+		doc = strings.TrimSpace(strings.TrimLeft(comment, "#"))
+		rest = prereqs
+	}
+	rest, inline, hasInline := strings.Cut(rest, ";")
+	prereqs, orderOnly, _ := strings.Cut(c.expandNames(rest), "|")
+	targets = c.expandNames(targets)
+	deps := append(strings.Fields(prereqs), strings.Fields(orderOnly)...)
+
+	if colons == "::" {
+		c.warn(line, "double-colon rule converted as an ordinary rule")
+	}
+	if strings.Contains(rest, "=") {
+		c.warn(line, "target-specific variable %q is not supported", strings.TrimSpace(targets+":"+rest))
+		return nil
+	}
+
+	var current []int
+	for _, target := range strings.Fields(targets) {
+		switch {
+		case target == ".PHONY":
+			for _, d := range deps {
+				c.phony[d] = true
+			}
+			continue
+		case strings.Contains(target, "%"):
+			c.warn(line, "pattern rule %q is not supported", target)
+			continue
+		case strings.HasPrefix(target, "."):
+			c.warn(line, "special target %s is not supported", target)
+			continue
+		case !justfile.IsName(target):
+			c.warn(line, "file target %q cannot be a recipe name", target)
+			continue
+		}
+
+		i, ok := c.recipes[target]
+		if !ok {
+			i = len(c.jf.Recipes)
+			c.recipes[target] = i
+			c.jf.Recipes = append(c.jf.Recipes, justfile.Recipe{Name: target, Line: line})
+		}
+		r := &c.jf.Recipes[i]
+		if doc != "" {
+			r.Doc = strings.TrimSpace(strings.TrimPrefix(doc, target+":"))
+		}
+		c.ruleDeps[target] = append(c.ruleDeps[target], deps...)
+		current = append(current, i)
+	}
+
+	if hasInline {
+		c.addRecipeLine(current, strings.TrimSpace(inline), line)
+	}
+	return current
+}
+
+// addRecipeLine converts a recipe line and appends it to each recipe.
+func (c *makeConverter) addRecipeLine(recipes []int, line string, lineNum int) {
+	if strings.TrimSpace(line) == "" {
+		return
+	}
+	for _, i := range recipes {
+		r := &c.jf.Recipes[i]
+		r.Lines = append(r.Lines, c.convertRecipeLine(r, line, lineNum))
+	}
+}
+
+// convertRecipeLine rewrites make references in a recipe line into their
+// justfile or shell equivalents.
+func (c *makeConverter) convertRecipeLine(r *justfile.Recipe, line string, lineNum int) string {
+	var convert func(string) string
+	convert = func(s string) string {
+		return rewriteMakeRefs(s, func(ref string) string {
+			switch ref {
+			case "$":
+				return "$"
+			case "@":
+				return r.Name
+			case "<":
+				if deps := c.ruleDeps[r.Name]; len(deps) > 0 {
+					return deps[0]
+				}
+				return ""
+			case "^":
+				var deps []string
+				for _, d := range c.ruleDeps[r.Name] {
+					if !slices.Contains(deps, d) {
+						deps = append(deps, d)
+					}
+				}
+				return strings.Join(deps, " ")
+			}
+			if cmd, ok := strings.CutPrefix(ref, "shell "); ok {
+				return "$(" + convert(cmd) + ")"
+			}
+			if _, ok := c.vars[ref]; ok {
+				return "{{" + ref + "}}"
+			}
+			if v, ok := makeBuiltins[ref]; ok {
+				return v
+			}
+			if slices.Contains(makeOnlyVars, ref) {
+				c.warn(lineNum, "recipe '%s': %s is make's own variable and is empty outside make", r.Name, makeRef(ref))
+			}
+			if justfile.IsName(ref) {
+				return "${" + ref + "}"
+			}
+			c.warn(lineNum, "recipe '%s': %s is not supported", r.Name, makeRef(ref))
+			return makeRef(ref)
+		})
+	}
+	// A literal "{{" would start an interpolation in the justfile.
+	return convert(strings.ReplaceAll(line, "{{", "{{{{"))
+}
+
+// makeBuiltins maps make's built-in variables to what they stand for in
+// a recipe line run by just. just runs recipes in the justfile's
+// directory, as make -C does.
+var makeBuiltins = map[string]string{
+	"MAKE":   "make",
+	"CURDIR": "${PWD}",
+}
+
+// makeOnlyVars are make's built-in variables with no equivalent outside
+// make. Converted recipe lines refer to them as shell variables, which are
+// normally unset.
+var makeOnlyVars = []string{
+	"MAKEFLAGS", "MFLAGS", "MAKECMDGOALS", "MAKELEVEL", "MAKEFILE_LIST",
+	"MAKEFILES", "MAKEOVERRIDES", "MAKE_VERSION", "MAKE_RESTARTS",
+}
+
+// finish resolves dependencies once every rule has been seen and puts the
+// default goal first.
+func (c *makeConverter) finish() {
+	for i := range c.jf.Recipes {
+		r := &c.jf.Recipes[i]
+		files := false
+		for _, d := range c.ruleDeps[r.Name] {
+			if slices.Contains(r.Dependencies, d) {
+				continue
+			}
+			if _, ok := c.recipes[d]; !ok {
+				c.warn(r.Line, "recipe '%s': prerequisite %q is not a recipe and was dropped", r.Name, d)
+				files = true
+				continue
+			}
+			r.Dependencies = append(r.Dependencies, d)
+		}
+		if files && !c.phony[r.Name] {
+			c.warn(r.Line, "recipe '%s': make only rebuilt this file target when out of date; the recipe always runs", r.Name)
+		}
+	}
+
+	if i, ok := c.recipes[c.goal]; ok && i > 0 {
+		goal := c.jf.Recipes[i]
+		c.jf.Recipes = slices.Insert(slices.Delete(c.jf.Recipes, i, i+1), 0, goal)
+	}
+}
+
+// makeRef returns the make syntax for a reference to ref.
+func makeRef(ref string) string {
+	if len(ref) == 1 {
+		return "$" + ref
+	}
+	return "$(" + ref + ")"
+}
+
+// rewriteMakeRefs calls repl for each make reference in s and substitutes
+// the result. repl receives the text inside $(...) or ${...}, or the
+// character after a lone $, which is "$" for an escaped dollar.
+func rewriteMakeRefs(s string, repl func(ref string) string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '$' || i+1 == len(s) {
+			b.WriteByte(s[i])
+			continue
+		}
+		switch s[i+1] {
+		case '(', '{':
+			end := matchingParen(s, i+1)
+			if end < 0 {
+				b.WriteString(s[i:])
+				return b.String()
+			}
+			b.WriteString(repl(s[i+2 : end]))
+			i = end
+		default:
+			b.WriteString(repl(s[i+1 : i+2]))
+			i++
+		}
+	}
+	return b.String()
+}
+
+// matchingParen returns the index of the bracket closing the one at s[open],
+// or -1 if it is not closed.
+func matchingParen(s string, open int) int {
+	opener := s[open]
+	closer := byte(')')
+	if opener == '{' {
+		closer = '}'
+	}
+	depth := 0
+	for i := open; i < len(s); i++ {
+		switch s[i] {
+		case opener:
+			depth++
+		case closer:
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
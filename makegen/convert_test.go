@@ -0,0 +1,103 @@
+package makegen
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/sammcj/jmake/justfile"
+)
+
+func TestConvert(t *testing.T) {
+	input := `.DEFAULT_GOAL := build
+NAME = app
+BIN := bin/$(NAME)
+FLAGS = -v
+FLAGS += -race
+VERSION != git describe
+DATE := $(shell date +%F)
+export CGO_ENABLED = 0
+
+## Run the tests
+.PHONY: test
+test: lint
+	go test $(FLAGS) ./... -run $(RUN)
+
+.PHONY: build lint
+lint:
+	@golangci-lint run $$PWD --format '{{.Name}}'
+
+build: lint ## Build the binary
+	-go build -o $(BIN) $(shell echo .) # $@
+`
+
+	jf, warnings, err := Convert(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	assertEqual(t, "warnings", len(warnings), 0)
+
+	want := `NAME := "app"
+BIN := "bin/app"
+FLAGS := "-v -race"
+VERSION := ` + "`git describe`" + `
+DATE := ` + "`date +%F`" + `
+export CGO_ENABLED := "0"
+
+# Build the binary
+build: lint
+    -go build -o {{BIN}} $(echo .) # build
+
+# Run the tests
+test: lint
+    go test {{FLAGS}} ./... -run ${RUN}
+
+lint:
+    @golangci-lint run $PWD --format '{{{{.Name}}'
+`
+	assertEqual(t, "justfile", justfile.Format(jf), want)
+}
+
+func TestConvertWarnings(t *testing.T) {
+	input := `ifdef CI
+MODE = ci
+else
+MODE = local
+endif
+
+%.o: %.c
+	cc -c $< -o $@
+
+app: main.go
+	go build -o $@
+
+all: app
+	echo $(wildcard *.go)
+	$(MAKE) -C lib $(MAKEFLAGS) CURDIR=$(CURDIR)
+`
+
+	jf, warnings, err := Convert(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	v := jf.Variables[0]
+	assertEqual(t, "first branch kept", v.Value, "ci")
+	assertEqual(t, "variables", len(jf.Variables), 1)
+	all := jf.FindRecipe("all")
+	assertEqual(t, "make built-ins", all.Lines[1], "make -C lib ${MAKEFLAGS} CURDIR=${PWD}")
+
+	want := []Warning{
+		{1, `conditional "ifdef CI" is not supported; only its first branch is converted`},
+		{7, `pattern rule "%.o" is not supported`},
+		{10, `recipe 'app': prerequisite "main.go" is not a recipe and was dropped`},
+		{10, `recipe 'app': make only rebuilt this file target when out of date; the recipe always runs`},
+		{14, `recipe 'all': $(wildcard *.go) is not supported`},
+		{15, `recipe 'all': $(MAKEFLAGS) is make's own variable and is empty outside make`},
+	}
+	if len(warnings) != len(want) {
+		t.Fatalf("got %d warnings, want %d: %v", len(warnings), len(want), warnings)
+	}
+	for i := range want {
+		assertEqual(t, "warning", warnings[i], want[i])
+	}
+}
@@ -0,0 +1,409 @@
+// Package makegen converts justfiles into Makefiles, and Makefiles into
+// justfiles.
+package makegen
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/sammcj/jmake/internal/glob"
+	"github.com/sammcj/jmake/justfile"
+)
+
+// Backtick expression -> $(shell ...)
+var backtickRe = regexp.MustCompile("`([^`]+)`")
+
+// Warning describes a construct that could not be converted, or was
+// converted with different behaviour.
+type Warning struct {
+	Line    int // line in the source file, 0 if unknown
+	Message string
+}
+
+// argsVar is the make variable that, in positional mode, holds recipe
+// arguments in order: make deploy ARGS="prod v1.2".
+const argsVar = "ARGS"
+
+// Generate produces Makefile content from a parsed Justfile. If the default
+// recipe calls `just --list` and opts.HelpTarget is set, a help target with
+// echo statements is generated in its place.
+func Generate(jf *justfile.Justfile, opts Options) string {
+	content, _, _ := GenerateWithSourceMap(jf, opts)
+	return content
+}
+
+// GenerateWithSourceMap is Generate, additionally returning a SourceMap from
+// the lines of the Makefile to the justfile lines they came from, and
+// warnings for constructs the chosen make flavour cannot express.
+func GenerateWithSourceMap(jf *justfile.Justfile, opts Options) (string, *SourceMap, []Warning) {
+	var b strings.Builder
+	smap := newSourceMap()
+	var warnings []Warning
+	gnu := opts.Flavour != FlavourPOSIX
+	listDefault := opts.HelpTarget != "" && jf.HasListDefault()
+
+	// mark maps the next line written to b to justfile line n, and writes
+	// the annotation comment if requested.
+	mark := func(n int) {
+		if opts.SourceComments && n > 0 {
+			fmt.Fprintf(&b, "# justfile:%d\n", n)
+		}
+		smap.add(strings.Count(b.String(), "\n")+1, n)
+	}
+
+	// phony declares a single target phony under the per-target policy.
+	phony := func(name string) {
+		if opts.Phony == PhonyPerTarget {
+			fmt.Fprintf(&b, ".PHONY: %s\n", name)
+		}
+	}
+
+	writeHeader(&b, opts)
+
+	// Collect all target names for .PHONY.
+	var phonyTargets []string
+	for _, r := range jf.Recipes {
+		phonyTargets = append(phonyTargets, r.Name)
+	}
+	for _, a := range jf.Aliases {
+		phonyTargets = append(phonyTargets, a.Name)
+	}
+	if listDefault {
+		phonyTargets = append(phonyTargets, opts.HelpTarget)
+	}
+	if opts.Phony == PhonyAll && len(phonyTargets) > 0 {
+		b.WriteString(".PHONY: ")
+		b.WriteString(strings.Join(phonyTargets, " "))
+		b.WriteString("\n\n")
+	}
+
+	// Variables.
+	for _, v := range jf.Variables {
+		prefix := ""
+		if v.Export && gnu {
+			prefix = "export "
+		} else if v.Export {
+			warnings = append(warnings, Warning{v.Line,
+				fmt.Sprintf("variable %s: POSIX make cannot export variables to recipes", v.Name)})
+		}
+		mark(v.Line)
+		switch {
+		case v.Backtick && gnu:
+			fmt.Fprintf(&b, "%s%s := $(shell %s)\n", prefix, v.Name, v.Value)
+		case v.Backtick:
+			fmt.Fprintf(&b, "%s != %s\n", v.Name, v.Value)
+		case gnu && strings.Contains(v.Value, "\n"):
+			// Values with line breaks, from indented strings or escapes,
+			// need a multi-line definition.
+			fmt.Fprintf(&b, "%sdefine %s\n%s\nendef\n", prefix, v.Name, v.Value)
+		case gnu:
+			fmt.Fprintf(&b, "%s%s := %s\n", prefix, v.Name, v.Value)
+		default:
+			value := v.Value
+			if strings.Contains(value, "\n") {
+				warnings = append(warnings, Warning{v.Line,
+					fmt.Sprintf("variable %s: POSIX make variables cannot hold line breaks; they become spaces", v.Name)})
+				value = strings.ReplaceAll(value, "\n", " ")
+			}
+			fmt.Fprintf(&b, "%s = %s\n", v.Name, value)
+		}
+	}
+	if len(jf.Variables) > 0 {
+		b.WriteString("\n")
+	}
+
+	// If listDefault, generate a help target as the first (default) target.
+	if listDefault {
+		phony(opts.HelpTarget)
+		writeHelpTarget(&b, jf, opts)
+	}
+
+	// Recipes.
+	for _, r := range jf.Recipes {
+		if listDefault && r.IsListDefault() {
+			continue // skip the original default recipe; replaced by help
+		}
+
+		b.WriteString(justfile.FormatComment(r.Doc))
+		phony(r.Name)
+
+		// Parameter defaults, as target-specific variables.
+		if gnu {
+			writeParamVars(&b, &r, opts.Positional)
+		} else if len(r.Params) > 0 {
+			warnings = append(warnings, Warning{r.Line,
+				fmt.Sprintf("recipe '%s': POSIX make has no per-recipe variables; parameter defaults and checks are dropped", r.Name)})
+		}
+
+		// Target line.
+		mark(r.Line)
+		if r.Incremental() && gnu {
+			writeFileTarget(&b, &r)
+		} else {
+			if r.Incremental() {
+				warnings = append(warnings, Warning{r.Line,
+					fmt.Sprintf("recipe '%s': inputs and outputs require GNU make; the recipe always runs", r.Name)})
+			}
+			b.WriteString(r.Name)
+			b.WriteString(":")
+			if len(r.Dependencies) > 0 {
+				b.WriteString(" ")
+				b.WriteString(strings.Join(r.Dependencies, " "))
+			}
+			b.WriteString("\n")
+		}
+
+		// Missing required parameters abort before the body runs.
+		if gnu {
+			for _, guard := range paramGuards(&r, opts.Positional) {
+				smap.add(strings.Count(b.String(), "\n")+1, r.Line)
+				fmt.Fprintf(&b, "\t%s\n", guard)
+			}
+		}
+
+		// Body lines. Backticks are left for the shell where make has no
+		// $(shell).
+		for i, line := range r.Lines {
+			smap.add(strings.Count(b.String(), "\n")+1, r.BodyLine(i))
+			converted := justfile.ExpandInterpolations(line, makeVar)
+			if gnu {
+				converted = convertLine(line)
+			}
+			fmt.Fprintf(&b, "\t%s\n", converted)
+		}
+
+		b.WriteString("\n")
+	}
+
+	// Aliases.
+	for _, a := range jf.Aliases {
+		phony(a.Name)
+		mark(a.Line)
+		fmt.Fprintf(&b, "%s: %s\n\n", a.Name, a.Target)
+	}
+
+	return b.String(), smap, warnings
+}
+
+// writeHeader writes the header comment, SHELL and any special targets,
+// followed by a blank line if it wrote anything.
+func writeHeader(b *strings.Builder, opts Options) {
+	start := b.Len()
+	if opts.Header != "" {
+		for line := range strings.SplitSeq(opts.Header, "\n") {
+			b.WriteString(strings.TrimRight("# "+line, " "))
+			b.WriteString("\n")
+		}
+	}
+	if opts.Shell != "" {
+		assign := ":="
+		if opts.Flavour == FlavourPOSIX {
+			assign = "="
+		}
+		fmt.Fprintf(b, "SHELL %s %s\n", assign, opts.Shell)
+	}
+	for _, special := range []struct {
+		name string
+		set  bool
+	}{
+		{".ONESHELL", opts.OneShell},
+		{".DELETE_ON_ERROR", opts.DeleteOnError},
+		{".SILENT", opts.Silent},
+	} {
+		if special.set {
+			fmt.Fprintf(b, "%s:\n", special.name)
+		}
+	}
+	if b.Len() > start {
+		b.WriteString("\n")
+	}
+}
+
+// writeParamVars writes target-specific assignments giving a recipe's
+// parameters their default values. They use ?= so that values passed on the
+// command line (make deploy env=prod) or from the environment win. In
+// positional mode a parameter also takes its value from the matching word
+// of ARGS, ahead of its default.
+func writeParamVars(b *strings.Builder, r *justfile.Recipe, positional bool) {
+	for i, p := range r.Params {
+		var value string
+		switch {
+		case positional && p.Name != argsVar && p.Variadic != "":
+			value = fmt.Sprintf("$(wordlist %d,$(words $(%s)),$(%s))", i+1, argsVar, argsVar)
+		case positional && p.Name != argsVar:
+			value = fmt.Sprintf("$(word %d,$(%s))", i+1, argsVar)
+			if p.HasDefault {
+				value = fmt.Sprintf("$(or %s,%s)", value, makeDefault(p))
+			}
+		case p.HasDefault:
+			value = makeDefault(p)
+		default:
+			continue
+		}
+		fmt.Fprintf(b, "%s: %s ?= %s\n", r.Name, p.Name, value)
+	}
+}
+
+// makeDefault returns a parameter's default as a make expression. A
+// variable or earlier parameter is referenced, and a backtick runs when
+// the parameter is used.
+func makeDefault(p justfile.Param) string {
+	switch p.DefaultKind {
+	case justfile.DefaultVariable:
+		return makeVar(p.Default)
+	case justfile.DefaultBacktick:
+		return "$(shell " + p.Default + ")"
+	}
+	return p.Default
+}
+
+// paramGuards returns recipe lines that stop make with an error when a
+// required parameter was not given. They expand to nothing otherwise. An
+// explicitly empty argument counts as given, as it does for just, so the
+// guards test where the variable came from rather than its value. In
+// positional mode a parameter is always assigned from its word of ARGS, so
+// an empty value from there counts as missing.
+func paramGuards(r *justfile.Recipe, positional bool) []string {
+	var guards []string
+	for _, p := range r.Params {
+		if p.HasDefault || p.Variadic == "*" {
+			continue
+		}
+		msg := fmt.Sprintf("recipe '%s' requires argument '%s'; usage: %s", r.Name, p.Name, makeUsage(r, positional))
+		if positional && p.Name != argsVar {
+			guards = append(guards, fmt.Sprintf("@$(if $(%s)$(filter-out file,$(origin %s)),,$(error %s))", p.Name, p.Name, msg))
+		} else {
+			guards = append(guards, fmt.Sprintf("@$(if $(filter undefined,$(origin %s)),$(error %s))", p.Name, msg))
+		}
+	}
+	return guards
+}
+
+// makeUsage returns an example make invocation for a recipe, naming its
+// parameters as variable assignments and, in positional mode, via ARGS.
+func makeUsage(r *justfile.Recipe, positional bool) string {
+	parts := []string{"make", r.Name}
+	var words []string
+	for _, p := range r.Params {
+		arg := p.Name + "=<" + p.Name + ">"
+		word := p.Name
+		switch {
+		case p.HasDefault:
+			arg = "[" + p.Name + "=" + makeDefault(p) + "]"
+			word = "[" + p.Name + "]"
+		case p.Variadic == "*":
+			arg = "[" + p.Name + "=<" + p.Name + "...>]"
+			word = "[" + p.Name + "...]"
+		case p.Variadic == "+":
+			arg = p.Name + "=<" + p.Name + "...>"
+			word = p.Name + "..."
+		}
+		parts = append(parts, arg)
+		words = append(words, word)
+	}
+	usage := strings.Join(parts, " ")
+	if positional && len(words) > 0 {
+		usage += " | make " + r.Name + " " + argsVar + "=\"" + strings.Join(words, " ") + "\""
+	}
+	return usage
+}
+
+// writeFileTarget writes the target lines for a recipe with declared outputs.
+// The recipe name stays a phony target that depends on its outputs, which
+// become real file targets with the inputs as prerequisites so make only
+// rebuilds them when stale. Recipe dependencies are order-only so that
+// phony dependencies don't force a rebuild on every run.
+func writeFileTarget(b *strings.Builder, r *justfile.Recipe) {
+	outputs := strings.Join(r.Outputs, " ")
+	fmt.Fprintf(b, "%s: %s\n", r.Name, outputs)
+
+	// Multiple outputs are produced together by one run of the recipe.
+	sep := ":"
+	if len(r.Outputs) > 1 {
+		sep = "&:"
+	}
+	b.WriteString(outputs)
+	b.WriteString(sep)
+	for _, in := range r.Inputs {
+		b.WriteString(" ")
+		b.WriteString(globToMake(in))
+	}
+	if len(r.Dependencies) > 0 {
+		b.WriteString(" | ")
+		b.WriteString(strings.Join(r.Dependencies, " "))
+	}
+	b.WriteString("\n")
+}
+
+// makeVar returns a reference to the make variable name, always in
+// parentheses.
+func makeVar(name string) string {
+	return "$(" + name + ")"
+}
+
+// convertLine transforms a single recipe body line from justfile to Makefile syntax.
+func convertLine(line string) string {
+	// Replace {{VAR}} with $(VAR).
+	line = justfile.ExpandInterpolations(line, makeVar)
+
+	// Replace `cmd` with $(shell cmd).
+	line = backtickRe.ReplaceAllString(line, "$$(shell $1)")
+
+	return line
+}
+
+// writeHelpTarget writes a Makefile help target that lists all recipes,
+// with a usage line for each recipe that takes parameters.
+func writeHelpTarget(b *strings.Builder, jf *justfile.Justfile, opts Options) {
+	b.WriteString("# Show available recipes\n")
+	b.WriteString(opts.HelpTarget + ":\n")
+	b.WriteString("\t@echo 'Available recipes:'\n")
+
+	for _, r := range jf.Recipes {
+		if r.IsListDefault() {
+			continue
+		}
+
+		label := r.Name
+
+		// Show parameters.
+		if len(r.Params) > 0 {
+			label += " " + justfile.FormatParams(r.Params)
+		}
+
+		if r.Doc != "" {
+			fmt.Fprintf(b, "\t@echo '    %-20s # %s'\n", label, r.Summary())
+		} else {
+			fmt.Fprintf(b, "\t@echo '    %s'\n", label)
+		}
+		if len(r.Params) > 0 {
+			fmt.Fprintf(b, "\t@echo %s\n", makeEchoArg("        usage: "+makeUsage(&r, opts.Positional)))
+		}
+	}
+	b.WriteString("\n")
+}
+
+// makeEchoArg single-quotes s for the shell and escapes $ for make, so that
+// an echo in a recipe prints s literally.
+func makeEchoArg(s string) string {
+	s = strings.ReplaceAll(s, "'", `'\''`)
+	s = strings.ReplaceAll(s, "$", "$$")
+	return "'" + s + "'"
+}
+
+// globToMake converts a glob into a make expression that expands to the
+// matching files. Plain globs use $(wildcard); "**" patterns fall back to
+// find(1), whose -path wildcards already match across directories.
+func globToMake(pattern string) string {
+	if !strings.Contains(pattern, "**") {
+		return "$(wildcard " + pattern + ")"
+	}
+	base := glob.Base(pattern)
+	findPat := strings.ReplaceAll(pattern, "**/", "")
+	findPat = strings.ReplaceAll(findPat, "**", "*")
+	if base == "." {
+		findPat = "./" + findPat
+	}
+	return "$(shell find " + base + " -type f -path '" + findPat + "' 2>/dev/null)"
+}
@@ -0,0 +1,267 @@
+package makegen
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/sammcj/jmake/justfile"
+)
+
+func TestConvertLine(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{
+			name:  "interpolation",
+			input: "echo {{NAME}}",
+			want:  "echo $(NAME)",
+		},
+		{
+			name:  "backtick in body",
+			input: "echo `git describe`",
+			want:  "echo $(shell git describe)",
+		},
+		{
+			name:  "mixed",
+			input: "deploy {{ENV}} `date`",
+			want:  "deploy $(ENV) $(shell date)",
+		},
+		{
+			name:  "escaped braces",
+			input: "echo {{{{x}} {{ y }}",
+			want:  "echo {{x}} $(y)",
+		},
+		{
+			name:  "no conversion needed",
+			input: "go build ./...",
+			want:  "go build ./...",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := convertLine(tt.input)
+			assertEqual(t, "converted line", got, tt.want)
+		})
+	}
+}
+
+func TestGenerateBrainiacMakefile(t *testing.T) {
+	input := `# Default recipe - show available commands
+default:
+    @just --list
+
+# Build the project
+build:
+    go build ./...
+
+# Run tests
+test:
+    go test ./...
+
+# Run the CLI
+cli *ARGS:
+    go run . -- {{ARGS}}
+`
+
+	jf, err := justfile.Parse(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	output := Generate(jf, DefaultOptions())
+
+	// Check header.
+	if !strings.Contains(output, "# Generated by jmake") {
+		t.Error("missing header comment")
+	}
+	if !strings.Contains(output, "SHELL := /bin/bash") {
+		t.Error("missing SHELL assignment")
+	}
+
+	// Check .PHONY includes help.
+	if !strings.Contains(output, ".PHONY:") {
+		t.Error("missing .PHONY")
+	}
+	if !strings.Contains(output, "help") {
+		t.Error("missing help in .PHONY")
+	}
+
+	// Check help target exists.
+	if !strings.Contains(output, "help:") {
+		t.Error("missing help target")
+	}
+
+	// Check recipe conversion.
+	if !strings.Contains(output, "build:") {
+		t.Error("missing build target")
+	}
+	if !strings.Contains(output, "\tgo build ./...") {
+		t.Error("missing build command")
+	}
+
+	// Check interpolation conversion.
+	if !strings.Contains(output, "$(ARGS)") {
+		t.Error("{{ARGS}} not converted to $(ARGS)")
+	}
+
+	// Default recipe (just --list) should not appear.
+	if strings.Contains(output, "default:") {
+		t.Error("default recipe should have been replaced by help")
+	}
+}
+
+func TestGenerateMultiLineVariable(t *testing.T) {
+	input := `export MOTD := """
+    hello
+      world
+"""
+`
+
+	jf, err := justfile.Parse(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	output := Generate(jf, DefaultOptions())
+	if !strings.Contains(output, "export define MOTD\nhello\n  world\n\nendef\n") {
+		t.Errorf("missing multi-line definition:\n%s", output)
+	}
+}
+
+func TestGenerateFileTarget(t *testing.T) {
+	input := `gen:
+	go generate
+
+[inputs("src/**/*.go")]
+[outputs("bin/app")]
+build: gen
+	go build -o bin/app
+`
+
+	jf, err := justfile.Parse(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	output := Generate(jf, DefaultOptions())
+
+	if !strings.Contains(output, "build: bin/app\n") {
+		t.Error("recipe should depend on its output file")
+	}
+	if !strings.Contains(output, "bin/app: $(shell find src -type f -path 'src/*.go' 2>/dev/null) | gen\n") {
+		t.Errorf("missing file target with inputs and order-only deps:\n%s", output)
+	}
+}
+
+func TestGenerateParamDefaultsAndGuards(t *testing.T) {
+	input := `deploy env tag="latest" note="" *rest:
+	echo {{env}} {{tag}} {{note}} {{rest}}
+`
+
+	jf, err := justfile.Parse(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	output := Generate(jf, DefaultOptions())
+
+	if !strings.Contains(output, "deploy: tag ?= latest\n") {
+		t.Errorf("missing default for tag:\n%s", output)
+	}
+	if strings.Contains(output, "deploy: env ?=") || strings.Contains(output, "deploy: rest ?=") {
+		t.Errorf("params without defaults should not be assigned:\n%s", output)
+	}
+	guard := "\t@$(if $(filter undefined,$(origin env)),$(error recipe 'deploy' requires argument 'env'; usage: make deploy env=<env> [tag=latest] [note=] [rest=<rest...>]))\n"
+	if !strings.Contains(output, "deploy:\n"+guard) {
+		t.Errorf("missing guard for required param env:\n%s", output)
+	}
+	if strings.Contains(output, "$(origin rest)") {
+		t.Error("optional variadic param should not be guarded")
+	}
+	if strings.Contains(output, "$(origin note)") {
+		t.Error("param with an empty default should not be guarded")
+	}
+}
+
+func TestGenerateExpressionDefaults(t *testing.T) {
+	input := `target := "linux"
+
+build os=target tag=` + "`git describe`" + `:
+	echo {{os}} {{tag}}
+`
+
+	jf, err := justfile.Parse(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	output := Generate(jf, DefaultOptions())
+	for _, want := range []string{
+		"build: os ?= $(target)\n",
+		"build: tag ?= $(shell git describe)\n",
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("missing %q in:\n%s", want, output)
+		}
+	}
+}
+
+func TestGeneratePositionalArgs(t *testing.T) {
+	input := `deploy env tag="latest" +files:
+	echo {{env}} {{tag}} {{files}}
+`
+
+	jf, err := justfile.Parse(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	opts := DefaultOptions()
+	opts.Positional = true
+	output, _, _ := GenerateWithSourceMap(jf, opts)
+
+	for _, want := range []string{
+		"deploy: env ?= $(word 1,$(ARGS))\n",
+		"deploy: tag ?= $(or $(word 2,$(ARGS)),latest)\n",
+		"deploy: files ?= $(wordlist 3,$(words $(ARGS)),$(ARGS))\n",
+		"$(if $(files)$(filter-out file,$(origin files)),,$(error recipe 'deploy' requires argument 'files'",
+		`| make deploy ARGS="env [tag] files..."`,
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("missing %q in:\n%s", want, output)
+		}
+	}
+}
+
+func TestHelpTargetUsage(t *testing.T) {
+	input := `default:
+	@just --list
+
+# Say hello
+hi who="world":
+	echo {{who}}
+`
+
+	jf, err := justfile.Parse(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	output := Generate(jf, DefaultOptions())
+
+	if !strings.Contains(output, "\t@echo '        usage: make hi [who=world]'\n") {
+		t.Errorf("help target should document params:\n%s", output)
+	}
+}
+
+// findTestRecipe is a test helper that finds a recipe by name.
+
+func assertEqual[T comparable](t *testing.T, label string, got, want T) {
+	t.Helper()
+	if got != want {
+		t.Errorf("%s: got %v, want %v", label, got, want)
+	}
+}
@@ -0,0 +1,200 @@
+package makegen
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/sammcj/jmake/justfile"
+)
+
+// ConfigFile is the name of the file holding a project's settings for
+// generated Makefiles, read by LoadConfig. jmake looks for it next to the
+// justfile.
+const ConfigFile = ".jmakerc"
+
+// Make flavours.
+const (
+	FlavourGNU   = "gnu"   // GNU make
+	FlavourPOSIX = "posix" // POSIX make (IEEE Std 1003.1-2024), e.g. BSD make
+)
+
+// Phony policies.
+const (
+	PhonyAll       = "all"        // one .PHONY line listing every target
+	PhonyPerTarget = "per-target" // a .PHONY line before each target
+	PhonyNone      = "none"       // no .PHONY declarations
+)
+
+// Options controls the Makefile that Generate produces.
+type Options struct {
+	Header     string // comment at the top, one "# " line per line; "" for none
+	Shell      string // value of SHELL; "" leaves make's default
+	Phony      string // PhonyAll, PhonyPerTarget or PhonyNone
+	HelpTarget string // replaces a `just --list` default recipe; "" keeps it
+	Flavour    string // FlavourGNU or FlavourPOSIX
+
+	SourceComments bool // precede variables and recipes with "# justfile:LINE"
+	Positional     bool // also accept recipe arguments via ARGS
+
+	OneShell      bool // .ONESHELL: run each recipe in a single shell
+	DeleteOnError bool // .DELETE_ON_ERROR: remove file targets a failed recipe left behind
+	Silent        bool // .SILENT: don't echo recipe lines
+}
+
+// DefaultOptions returns the options jmake uses unless told otherwise.
+func DefaultOptions() Options {
+	return Options{
+		Header:     "Generated by jmake - do not edit",
+		Shell:      "/bin/bash",
+		Phony:      PhonyAll,
+		HelpTarget: "help",
+		Flavour:    FlavourGNU,
+	}
+}
+
+// Runtime returns the options for a Makefile that jmake runs itself
+// rather than one users keep: the defaults, with only the settings that
+// change how recipes run. House style such as the phony policy or flavour
+// could otherwise stop recipes from running as jmake expects.
+func (o Options) Runtime() Options {
+	r := DefaultOptions()
+	r.Shell = o.Shell
+	r.OneShell = o.OneShell
+	r.DeleteOnError = o.DeleteOnError
+	r.Silent = o.Silent
+	return r
+}
+
+// IsSetting reports whether name is a setting accepted by Set.
+func IsSetting(name string) bool {
+	switch name {
+	case "make-header", "make-shell", "make-phony", "make-help-target", "make-flavour", "make-flavor":
+		return true
+	}
+	return IsBoolSetting(name)
+}
+
+// IsBoolSetting reports whether the named setting takes a boolean.
+func IsBoolSetting(name string) bool {
+	switch name {
+	case "annotate", "positional-args", "make-oneshell", "make-delete-on-error", "make-silent":
+		return true
+	}
+	return false
+}
+
+// Set applies a single setting. Settings are named as jmake's command-line
+// flags without their leading dashes, such as "make-shell"; booleans take
+// any value strconv.ParseBool accepts. In "make-header", \n starts a new
+// line.
+func (o *Options) Set(name, value string) error {
+	var flag *bool
+	switch name {
+	case "make-header":
+		o.Header = strings.ReplaceAll(value, `\n`, "\n")
+	case "make-shell":
+		o.Shell = value
+	case "make-phony":
+		o.Phony = value
+	case "make-help-target":
+		o.HelpTarget = value
+	case "make-flavour", "make-flavor":
+		o.Flavour = value
+	case "annotate":
+		flag = &o.SourceComments
+	case "positional-args":
+		flag = &o.Positional
+	case "make-oneshell":
+		flag = &o.OneShell
+	case "make-delete-on-error":
+		flag = &o.DeleteOnError
+	case "make-silent":
+		flag = &o.Silent
+	default:
+		return fmt.Errorf("unknown setting %q", name)
+	}
+	if flag != nil {
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("%s: invalid boolean %q", name, value)
+		}
+		*flag = b
+	}
+	return nil
+}
+
+// Validate checks that the options are recognised and consistent.
+func (o *Options) Validate() error {
+	switch o.Phony {
+	case PhonyAll, PhonyPerTarget, PhonyNone:
+	default:
+		return fmt.Errorf("invalid phony policy %q (want all, per-target or none)", o.Phony)
+	}
+	if o.HelpTarget != "" && !justfile.IsName(o.HelpTarget) {
+		return fmt.Errorf("invalid help target name %q", o.HelpTarget)
+	}
+	switch o.Flavour {
+	case FlavourGNU:
+	case FlavourPOSIX:
+		for _, gnuOnly := range []struct {
+			flag string
+			set  bool
+		}{
+			{"--make-oneshell", o.OneShell},
+			{"--make-delete-on-error", o.DeleteOnError},
+			{"--positional-args", o.Positional},
+		} {
+			if gnuOnly.set {
+				return fmt.Errorf("%s requires the gnu make flavour", gnuOnly.flag)
+			}
+		}
+	default:
+		return fmt.Errorf("invalid make flavour %q (want gnu or posix)", o.Flavour)
+	}
+	return nil
+}
+
+// LoadConfig applies the settings in the config file at path to opts. Each
+// line is "name = value", naming a setting as Set does; the value may be
+// quoted, and lines starting with # are comments. A missing file is not an
+// error. LoadConfig does not validate the result.
+func LoadConfig(path string, opts *Options) error {
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("reading config: %w", err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		name, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return fmt.Errorf("%s:%d: expected name = value", path, lineNum)
+		}
+		value = strings.TrimSpace(value)
+		if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+			value = value[1 : len(value)-1]
+		}
+		if err := opts.Set(strings.TrimSpace(name), value); err != nil {
+			return fmt.Errorf("%s:%d: %w", path, lineNum, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("reading config: %w", err)
+	}
+	return nil
+}
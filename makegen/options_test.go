@@ -0,0 +1,160 @@
+package makegen
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/sammcj/jmake/justfile"
+)
+
+func TestDefaultOptionsUnchanged(t *testing.T) {
+	jf, err := justfile.Parse(strings.NewReader("build:\n\tgo build\n"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	output := Generate(jf, DefaultOptions())
+
+	want := "# Generated by jmake - do not edit\nSHELL := /bin/bash\n\n.PHONY: build\n\nbuild:\n\tgo build\n\n"
+	assertEqual(t, "output", output, want)
+}
+
+func TestGenerateHouseStyle(t *testing.T) {
+	input := `default:
+	@just --list
+
+build:
+	go build
+
+alias b := build
+`
+	jf, err := justfile.Parse(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	opts := DefaultOptions()
+	opts.Header = "Do not edit.\n\nRegenerate with: jmake --dump -o Makefile"
+	opts.Shell = "/bin/sh"
+	opts.Phony = PhonyPerTarget
+	opts.HelpTarget = "usage"
+	opts.OneShell = true
+	opts.DeleteOnError = true
+	opts.Silent = true
+
+	output := Generate(jf, opts)
+
+	for _, want := range []string{
+		"# Do not edit.\n#\n# Regenerate with: jmake --dump -o Makefile\nSHELL := /bin/sh\n.ONESHELL:\n.DELETE_ON_ERROR:\n.SILENT:\n\n",
+		".PHONY: usage\n# Show available recipes\nusage:\n",
+		".PHONY: build\nbuild:\n",
+		".PHONY: b\nb: build\n",
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("missing %q in:\n%s", want, output)
+		}
+	}
+	if strings.Contains(output, "help") {
+		t.Errorf("help target should be renamed:\n%s", output)
+	}
+}
+
+func TestGenerateNoHelpTargetOrPhony(t *testing.T) {
+	input := `default:
+	@just --list
+
+build:
+	go build
+`
+	jf, err := justfile.Parse(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	opts := DefaultOptions()
+	opts.Header = ""
+	opts.Shell = ""
+	opts.Phony = PhonyNone
+	opts.HelpTarget = ""
+
+	output := Generate(jf, opts)
+
+	want := "default:\n\t@just --list\n\nbuild:\n\tgo build\n\n"
+	assertEqual(t, "output", output, want)
+}
+
+func TestGeneratePOSIXFlavour(t *testing.T) {
+	input := `export GREETING := "hello"
+rev := ` + "`git rev-parse HEAD`" + `
+motd := "one\ntwo"
+
+deploy env:
+	echo {{env}} ` + "`date`" + `
+
+[inputs("*.go")]
+[outputs("app")]
+build:
+	go build
+`
+	jf, err := justfile.Parse(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	opts := DefaultOptions()
+	opts.Flavour = FlavourPOSIX
+	content, _, warnings := GenerateWithSourceMap(jf, opts)
+
+	for _, want := range []string{
+		"SHELL = /bin/bash\n",
+		"GREETING = hello\n",
+		"rev != git rev-parse HEAD\n",
+		"motd = one two\n",
+		"deploy:\n\techo $(env) `date`\n",
+		"build:\n\tgo build\n",
+	} {
+		if !strings.Contains(content, want) {
+			t.Errorf("missing %q in:\n%s", want, content)
+		}
+	}
+	for _, unwanted := range []string{"export", ":=", "?=", "$(if", "$(shell", "$(wildcard"} {
+		if strings.Contains(content, unwanted) {
+			t.Errorf("POSIX output contains %q:\n%s", unwanted, content)
+		}
+	}
+
+	var got []string
+	for _, w := range warnings {
+		got = append(got, w.Message)
+	}
+	assertEqual(t, "warnings", strings.Join(got, "\n"), strings.Join([]string{
+		"variable GREETING: POSIX make cannot export variables to recipes",
+		"variable motd: POSIX make variables cannot hold line breaks; they become spaces",
+		"recipe 'deploy': POSIX make has no per-recipe variables; parameter defaults and checks are dropped",
+		"recipe 'build': inputs and outputs require GNU make; the recipe always runs",
+	}, "\n"))
+}
+
+func TestRuntimeOptions(t *testing.T) {
+	opts := DefaultOptions()
+	for _, s := range [][2]string{
+		{"make-header", "house"},
+		{"make-phony", PhonyNone},
+		{"make-flavour", FlavourPOSIX},
+		{"make-help-target", ""},
+		{"annotate", "true"},
+		{"make-shell", "/bin/sh"},
+		{"make-oneshell", "true"},
+		{"make-silent", "true"},
+	} {
+		if err := opts.Set(s[0], s[1]); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	want := DefaultOptions()
+	want.Shell = "/bin/sh"
+	want.OneShell = true
+	want.Silent = true
+	assertEqual(t, "runtime options", opts.Runtime(), want)
+}
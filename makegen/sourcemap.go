@@ -0,0 +1,66 @@
+package makegen
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// SourceMap relates lines of a generated Makefile to the justfile lines
+// they were generated from. Line numbers on both sides are 1-based.
+type SourceMap struct {
+	lines map[int]int
+}
+
+func newSourceMap() *SourceMap {
+	return &SourceMap{lines: make(map[int]int)}
+}
+
+// add records that Makefile line makeLine came from justfile line justLine.
+func (m *SourceMap) add(makeLine, justLine int) {
+	if justLine > 0 {
+		m.lines[makeLine] = justLine
+	}
+}
+
+// Lookup returns the justfile line that Makefile line makeLine was
+// generated from. Lines with no justfile counterpart, such as the header
+// or the generated help target, report false.
+func (m *SourceMap) Lookup(makeLine int) (int, bool) {
+	if m == nil {
+		return 0, false
+	}
+	l, ok := m.lines[makeLine]
+	return l, ok
+}
+
+// MakefileLines returns the mapped Makefile lines in ascending order.
+func (m *SourceMap) MakefileLines() []int {
+	out := make([]int, 0, len(m.lines))
+	for l := range m.lines {
+		out = append(out, l)
+	}
+	sort.Ints(out)
+	return out
+}
+
+// Translate rewrites references of the form "makefilePath:LINE" in msg, as
+// make uses in its diagnostics, into "justfilePath:LINE" for the justfile
+// line they map to. References to unmapped lines are left alone.
+func (m *SourceMap) Translate(msg, makefilePath, justfilePath string) string {
+	if m == nil || makefilePath == "" || !strings.Contains(msg, makefilePath) {
+		return msg
+	}
+	re := regexp.MustCompile(regexp.QuoteMeta(makefilePath) + `:(\d+)`)
+	return re.ReplaceAllStringFunc(msg, func(ref string) string {
+		n, err := strconv.Atoi(ref[len(makefilePath)+1:])
+		if err != nil {
+			return ref
+		}
+		if l, ok := m.Lookup(n); ok {
+			return justfilePath + ":" + strconv.Itoa(l)
+		}
+		return ref
+	})
+}
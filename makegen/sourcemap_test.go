@@ -0,0 +1,67 @@
+package makegen
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/sammcj/jmake/justfile"
+)
+
+func TestGenerateLineMap(t *testing.T) {
+	input := `# Build it
+build:
+	echo one
+	echo two
+`
+	jf, err := justfile.Parse(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	content, smap, _ := GenerateWithSourceMap(jf, DefaultOptions())
+	makeLines := strings.Split(content, "\n")
+	for _, makeLine := range smap.MakefileLines() {
+		justLine, _ := smap.Lookup(makeLine)
+		got := strings.TrimPrefix(makeLines[makeLine-1], "\t")
+		want := strings.TrimPrefix(strings.Split(input, "\n")[justLine-1], "\t")
+		assertEqual(t, "mapped line", got, want)
+	}
+	assertEqual(t, "mapped lines", len(smap.MakefileLines()), 3)
+}
+
+func TestGenerateAnnotated(t *testing.T) {
+	input := `version := "1.0"
+
+build:
+	echo {{version}}
+`
+	jf, err := justfile.Parse(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	opts := DefaultOptions()
+	opts.SourceComments = true
+	content, smap, _ := GenerateWithSourceMap(jf, opts)
+	if !strings.Contains(content, "# justfile:1\nversion := 1.0\n") {
+		t.Errorf("missing variable annotation:\n%s", content)
+	}
+	if !strings.Contains(content, "# justfile:3\nbuild:\n") {
+		t.Errorf("missing recipe annotation:\n%s", content)
+	}
+
+	// The body line follows the target line.
+	var bodyLine int
+	for i, l := range strings.Split(content, "\n") {
+		if l == "\techo $(version)" {
+			bodyLine = i + 1
+		}
+	}
+	justLine, ok := smap.Lookup(bodyLine)
+	assertEqual(t, "body maps", ok, true)
+	assertEqual(t, "body line", justLine, 4)
+
+	msg := smap.Translate("/tmp/x.mk:"+strconv.Itoa(bodyLine)+": *** missing separator.  Stop.", "/tmp/x.mk", "justfile")
+	assertEqual(t, "translated", msg, "justfile:4: *** missing separator.  Stop.")
+}
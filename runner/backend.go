@@ -0,0 +1,202 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"slices"
+	"strings"
+
+	"github.com/sammcj/jmake/justfile"
+	"github.com/sammcj/jmake/makegen"
+)
+
+// Backend converts a parsed justfile into the input of another tool, and
+// runs recipes with it.
+type Backend interface {
+	// Name identifies the backend, as given to --backend.
+	Name() string
+
+	// Ext is the file extension for the generated output, such as ".mk",
+	// or "" if the backend runs recipes without generating anything.
+	Ext() string
+
+	// Generate converts the justfile. Options that don't apply to the
+	// backend's output are ignored.
+	Generate(jf *justfile.Justfile, opts makegen.Options) Generated
+
+	// Run runs a single recipe. The Executor has already run its
+	// dependencies, and written the output of Generate to job.File.
+	Run(ctx context.Context, job *Job) error
+}
+
+// Generated is a backend's conversion of a justfile.
+type Generated struct {
+	Content string
+
+	// Lines maps lines of Content to the justfile lines they came from,
+	// if the backend tracks them.
+	Lines *makegen.SourceMap
+
+	// Warnings describe constructs that could not be carried over
+	// faithfully.
+	Warnings []makegen.Warning
+}
+
+// backends holds a constructor for each registered backend. Backends may
+// keep state for the duration of a run, so each run gets a new one.
+var backends = make(map[string]func() Backend)
+
+// RegisterBackend makes a backend available to --backend under its name.
+func RegisterBackend(newBackend func() Backend) {
+	backends[newBackend().Name()] = newBackend
+}
+
+// LookupBackend returns a new instance of the named backend. An empty name
+// selects make.
+func LookupBackend(name string) (Backend, error) {
+	if name == "" {
+		name = "make"
+	}
+	if newBackend, ok := backends[name]; ok {
+		return newBackend(), nil
+	}
+	return nil, fmt.Errorf("unknown backend %q (want %s)", name, strings.Join(BackendNames(), ", "))
+}
+
+// BackendNames returns the names of the registered backends, sorted.
+func BackendNames() []string {
+	var names []string
+	for name := range backends {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// Job asks a backend to run one recipe.
+type Job struct {
+	Recipe  *justfile.Recipe
+	Args    []string           // arguments as given on the command line
+	Vars    []string           // the arguments as NAME=value parameter assignments
+	Done    []string           // transitive dependencies, already run
+	Rebuild bool               // jmake found the recipe's outputs stale
+	DryRun  bool               // print what would run instead of running it
+	File    string             // the generated output, if the backend has any
+	Lines   *makegen.SourceMap // from Generated.Lines
+
+	Stdout io.Writer
+	Stderr io.Writer
+
+	e *Executor
+}
+
+// Exec runs cmd in the justfile's directory, tracking the process so that
+// signals sent to jmake reach it. Standard output and error default to the
+// recipe's.
+func (j *Job) Exec(cmd *exec.Cmd) error {
+	if cmd.Stdout == nil {
+		cmd.Stdout = j.Stdout
+	}
+	if cmd.Stderr == nil {
+		cmd.Stderr = j.Stderr
+	}
+	cmd.Stdin = j.e.Stdin
+	cmd.Dir = j.e.dir
+	if j.e.ProcessGroups {
+		setProcessGroup(cmd)
+	}
+	if err := j.e.start(cmd); err != nil {
+		return err
+	}
+	err := cmd.Wait()
+	j.e.finished(cmd.Process)
+	return err
+}
+
+func init() {
+	RegisterBackend(func() Backend { return makeBackend{} })
+}
+
+// makeBackend generates a Makefile and runs each recipe with make.
+type makeBackend struct{}
+
+func (makeBackend) Name() string { return "make" }
+func (makeBackend) Ext() string  { return ".mk" }
+
+func (makeBackend) Generate(jf *justfile.Justfile, opts makegen.Options) Generated {
+	content, lines, warnings := makegen.GenerateWithSourceMap(jf, opts)
+	return Generated{Content: content, Lines: lines, Warnings: warnings}
+}
+
+// Run invokes make for the recipe's target alone: dependencies already run
+// are passed with -o so they are not rebuilt. make's own error messages are
+// replaced by one naming the failed justfile line.
+func (makeBackend) Run(ctx context.Context, j *Job) error {
+	args := []string{"--no-print-directory", "-f", j.File}
+	for _, dep := range j.Done {
+		args = append(args, "-o", dep)
+	}
+	// jmake has already decided the outputs are stale, and mtimes alone may
+	// disagree (e.g. after a checkout), so make must rebuild them.
+	if j.Rebuild {
+		args = append(args, "-B")
+	}
+	args = append(args, j.Recipe.Name)
+	args = append(args, j.Vars...)
+
+	if j.DryRun {
+		fmt.Fprintf(j.Stdout, "make %s\n", strings.Join(args, " "))
+		return nil
+	}
+
+	stderr := &makeErrorFilter{w: j.Stderr, translate: func(msg string) string {
+		return j.Lines.Translate(msg, j.File, j.e.JustfilePath)
+	}}
+	cmd := exec.Command("make", args...)
+	cmd.Stderr = stderr
+	err := j.Exec(cmd)
+	_ = stderr.Flush()
+	if err != nil {
+		return makeFailure(j, err, stderr)
+	}
+	return nil
+}
+
+// makeFailure converts make's exit status into the exit status of the
+// recipe command that failed, located in the justfile where possible.
+func makeFailure(j *Job, err error, stderr *makeErrorFilter) error {
+	var ee *exec.ExitError
+	if !errors.As(err, &ee) {
+		return err
+	}
+	line, code, ok := stderr.failure()
+	if !ok {
+		// make failed before running any command, and has said why.
+		return &RecipeError{Recipe: j.Recipe.Name, Code: ee.ExitCode()}
+	}
+	if code == 0 {
+		// Killed by a signal rather than exiting; nothing more to add.
+		return err
+	}
+	justLine, _ := j.Lines.Lookup(line)
+	return &RecipeError{Recipe: j.Recipe.Name, Line: justLine, Code: code}
+}
+
+// expressionWarnings reports interpolations in a recipe's body that are
+// expressions rather than plain names, which backends pass through as if
+// they were names.
+func expressionWarnings(r *justfile.Recipe) []makegen.Warning {
+	var warnings []makegen.Warning
+	for i, line := range r.Lines {
+		for _, frag := range justfile.Fragments(line) {
+			if frag.Interpolation && !justfile.IsName(frag.Text) {
+				warnings = append(warnings, makegen.Warning{Line: r.BodyLine(i),
+					Message: fmt.Sprintf("recipe '%s': expression {{%s}} is not supported", r.Name, frag.Text)})
+			}
+		}
+	}
+	return warnings
+}
@@ -0,0 +1,284 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sammcj/jmake/justfile"
+	"github.com/sammcj/jmake/makegen"
+)
+
+func TestLookupBackend(t *testing.T) {
+	for _, name := range []string{"make", "native", "sh", "taskfile"} {
+		b, err := LookupBackend(name)
+		if err != nil {
+			t.Fatalf("%q: unexpected error: %v", name, err)
+		}
+		assertEqual(t, "name", b.Name(), name)
+	}
+
+	b, err := LookupBackend("")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	assertEqual(t, "default backend", b.Name(), "make")
+
+	_, err = LookupBackend("ninja")
+	if err == nil {
+		t.Fatal("expected error for unknown backend")
+	}
+	assertEqual(t, "error", err.Error(), `unknown backend "ninja" (want make, native, sh, taskfile)`)
+}
+
+// runWithBackend runs target from a justfile with the named backend,
+// returning what the recipes wrote to stdout and stderr.
+func runWithBackend(t *testing.T, backend, input, target string, args ...string) (string, string, error) {
+	t.Helper()
+	jf, err := justfile.Parse(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	b, err := LookupBackend(backend)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout, stderr strings.Builder
+	ex := NewExecutor(jf, b, t.TempDir(), 1, NewOutput(OutputInterleaved, &stdout, &stderr, false))
+	ex.Stdin = nil
+	if err := ex.Load(b.Generate(jf, makegen.DefaultOptions())); err != nil {
+		t.Fatal(err)
+	}
+	defer ex.Close()
+	err = ex.Run(context.Background(), target, args)
+	return stdout.String(), stderr.String(), err
+}
+
+func TestNativeBackend(t *testing.T) {
+	input := `greeting := "hello"
+export WHO := "world"
+day := ` + "`echo monday`" + `
+
+build:
+	echo {{greeting}} $WHO on {{day}} {{{{literal}}
+
+deploy env tag="latest": build
+	@echo deploying {{tag}} to {{env}}
+	-false
+	@exit 4
+`
+
+	stdout, stderr, err := runWithBackend(t, "native", input, "deploy", "prod")
+
+	assertEqual(t, "stdout", stdout, "hello world on monday {{literal}}\ndeploying latest to prod\n")
+	assertEqual(t, "stderr", stderr, "echo hello $WHO on monday {{literal}}\nfalse\n")
+
+	var re *RecipeError
+	if !errors.As(err, &re) {
+		t.Fatalf("expected RecipeError, got %v", err)
+	}
+	assertEqual(t, "recipe", re.Recipe, "deploy")
+	assertEqual(t, "line", re.Line, 11)
+	assertEqual(t, "code", re.Code, 4)
+}
+
+func TestNativeBackendUndefinedVariable(t *testing.T) {
+	_, _, err := runWithBackend(t, "native", "a:\n\techo {{nope}}\n", "a")
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	assertEqual(t, "error", err.Error(), "recipe 'a' line 2: variable 'nope' not defined")
+}
+
+func TestNativeBackendLineTimings(t *testing.T) {
+	jf, err := justfile.Parse(strings.NewReader("a:\n\ttrue\n\n\t-exit 3\n"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	b, _ := LookupBackend("native")
+	var out strings.Builder
+	ex := NewExecutor(jf, b, t.TempDir(), 1, NewOutput(OutputInterleaved, &out, &out, false))
+	ex.Stdin = nil
+	ex.Timings = NewTimings()
+	if err := ex.Load(b.Generate(jf, makegen.DefaultOptions())); err != nil {
+		t.Fatal(err)
+	}
+	defer ex.Close()
+	if err := ex.Run(context.Background(), "a", nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var lines []string
+	for _, r := range ex.Timings.sorted() {
+		lines = append(lines, fmt.Sprintf("%s:%d=%d", r.Name, r.Line, r.ExitCode))
+	}
+	assertEqual(t, "records", strings.Join(lines, " "), "a:0=0 a:2=0 a:4=3")
+}
+
+func TestShellBackendSkipsDone(t *testing.T) {
+	input := `a:
+	@echo a
+
+b: a
+	@echo b
+
+c: a b
+	@echo c
+`
+
+	stdout, _, err := runWithBackend(t, "sh", input, "c")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	assertEqual(t, "stdout", stdout, "a\nb\nc\n")
+}
+
+func TestDefaultExpressions(t *testing.T) {
+	input := `target := "linux"
+
+build os=target tag=` + "`echo v1`" + ` *flags=os:
+	@echo {{os}} {{tag}} {{flags}}
+`
+
+	for _, backend := range []string{"native", "make", "sh"} {
+		if backend == "make" {
+			if _, err := exec.LookPath("make"); err != nil {
+				continue
+			}
+		}
+		stdout, _, err := runWithBackend(t, backend, input, "build")
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", backend, err)
+		}
+		assertEqual(t, backend+" defaults", stdout, "linux v1 linux\n")
+
+		stdout, _, _ = runWithBackend(t, backend, input, "build", "darwin")
+		assertEqual(t, backend+" argument", stdout, "darwin v1 darwin\n")
+	}
+}
+
+// requireMake skips the test when make is not installed.
+func requireMake(t *testing.T) {
+	t.Helper()
+	if _, err := exec.LookPath("make"); err != nil {
+		t.Skip("make not found")
+	}
+}
+
+func TestMakeBackend(t *testing.T) {
+	requireMake(t)
+	input := `greeting := "hello"
+day := ` + "`echo monday`" + `
+
+build:
+	echo {{greeting}} on {{day}}
+
+deploy env tag="latest": build
+	@echo deploying {{tag}} to {{env}}
+	-false
+	@exit 4
+`
+
+	stdout, stderr, err := runWithBackend(t, "make", input, "deploy", "prod")
+
+	// make echoes commands to stdout; its own messages about the ignored
+	// and the fatal failure are removed from stderr.
+	assertEqual(t, "stdout", stdout, "echo hello on monday\nhello on monday\ndeploying latest to prod\nfalse\n")
+	assertEqual(t, "stderr", stderr, "")
+
+	var re *RecipeError
+	if !errors.As(err, &re) {
+		t.Fatalf("expected RecipeError, got %v", err)
+	}
+	assertEqual(t, "recipe", re.Recipe, "deploy")
+	assertEqual(t, "line", re.Line, 10)
+	assertEqual(t, "code", re.Code, 4)
+}
+
+func TestMakeBackendEmptyArgument(t *testing.T) {
+	requireMake(t)
+	stdout, _, err := runWithBackend(t, "make", "greet name:\n\t@echo \"[{{name}}]\"\n", "greet", "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	assertEqual(t, "stdout", stdout, "[]\n")
+}
+
+func TestMakeBackendSkipsDone(t *testing.T) {
+	requireMake(t)
+	input := `a:
+	@echo a
+
+b: a
+	@echo b
+
+c: a b
+	@echo c
+`
+
+	stdout, _, err := runWithBackend(t, "make", input, "c")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	assertEqual(t, "stdout", stdout, "a\nb\nc\n")
+}
+
+func TestMakeBackendRebuildsStaleOutputs(t *testing.T) {
+	requireMake(t)
+	dir := t.TempDir()
+	in, out := filepath.Join(dir, "in.txt"), filepath.Join(dir, "out.txt")
+	if err := os.WriteFile(in, []byte("new\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(out, []byte("old\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	// The output is newer than its input, so make alone would skip it.
+	past := time.Now().Add(-time.Hour)
+	if err := os.Chtimes(in, past, past); err != nil {
+		t.Fatal(err)
+	}
+
+	jf, err := justfile.Parse(strings.NewReader(`[inputs("in.txt")]
+[outputs("out.txt")]
+gen:
+	@cp in.txt out.txt
+`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	b, _ := LookupBackend("make")
+	var stdout, stderr strings.Builder
+	ex := NewExecutor(jf, b, dir, 1, NewOutput(OutputInterleaved, &stdout, &stderr, false))
+	ex.Stdin = nil
+	if ex.Cache, err = LoadCache(dir); err != nil {
+		t.Fatal(err)
+	}
+	if err := ex.Load(b.Generate(jf, makegen.DefaultOptions())); err != nil {
+		t.Fatal(err)
+	}
+	defer ex.Close()
+	if err := ex.Run(context.Background(), "gen", nil); err != nil {
+		t.Fatalf("unexpected error: %v\n%s", err, stderr.String())
+	}
+
+	got, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, "output", string(got), "new\n")
+}
+
+func assertEqual[T comparable](t *testing.T, label string, got, want T) {
+	t.Helper()
+	if got != want {
+		t.Errorf("%s: got %v, want %v", label, got, want)
+	}
+}
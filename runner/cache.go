@@ -0,0 +1,321 @@
+package runner
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+
+	"github.com/sammcj/jmake/internal/glob"
+	"github.com/sammcj/jmake/justfile"
+)
+
+// CacheDir is the directory, relative to the justfile, where jmake keeps
+// state between runs.
+const CacheDir = ".jmake"
+
+// cacheFile is the name of the fingerprint store within CacheDir.
+const cacheFile = "fingerprints.json"
+
+var (
+	// Shell-style environment references in recipe bodies: $NAME or ${NAME}.
+	envRefRe = regexp.MustCompile(`\$\{?([A-Za-z_][A-Za-z0-9_]*)\}?`)
+
+	// just's env_var("NAME") and env_var_or_default("NAME", ...) functions.
+	envFuncRe = regexp.MustCompile(`env_var(?:_or_default)?\(\s*["']([^"']+)["']`)
+)
+
+// fingerprint identifies everything that can affect a recipe's result.
+// Each component is hashed separately so that --cache-status can say which
+// one changed.
+type fingerprint struct {
+	Inputs string `json:"inputs"` // paths and contents of input files
+	Body   string `json:"body"`   // recipe body with interpolations evaluated
+	Params string `json:"params"` // parameter values
+	Env    string `json:"env"`    // referenced environment variables
+}
+
+// changed returns the names of the components that differ from old.
+func (f fingerprint) changed(old fingerprint) []string {
+	var out []string
+	if f.Inputs != old.Inputs {
+		out = append(out, "inputs")
+	}
+	if f.Body != old.Body {
+		out = append(out, "recipe body")
+	}
+	if f.Params != old.Params {
+		out = append(out, "parameters")
+	}
+	if f.Env != old.Env {
+		out = append(out, "environment")
+	}
+	return out
+}
+
+// Cache stores the fingerprint each recipe had the last time it
+// ran successfully, persisted as JSON under CacheDir.
+type Cache struct {
+	path string
+
+	mu      sync.Mutex
+	entries map[string]fingerprint
+}
+
+// LoadCache reads the fingerprint cache for the justfile in dir. A missing
+// cache yields an empty one, as does an unreadable one: the cache only
+// ever saves work, so discarding it is always safe.
+func LoadCache(dir string) (*Cache, error) {
+	c := &Cache{
+		path:    filepath.Join(dir, CacheDir, cacheFile),
+		entries: make(map[string]fingerprint),
+	}
+	data, err := os.ReadFile(c.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return c, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading cache: %w", err)
+	}
+	if err := json.Unmarshal(data, &c.entries); err != nil {
+		c.entries = make(map[string]fingerprint)
+	}
+	return c, nil
+}
+
+// get returns the stored fingerprint for a recipe.
+func (c *Cache) get(name string) (fingerprint, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	fp, ok := c.entries[name]
+	return fp, ok
+}
+
+// set records a recipe's fingerprint and writes the cache to disk.
+func (c *Cache) set(name string, fp fingerprint) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[name] = fp
+
+	data, err := json.MarshalIndent(c.entries, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(c.path), 0o755); err != nil {
+		return fmt.Errorf("creating cache directory: %w", err)
+	}
+	// Write via a temp file so concurrent readers never see a partial cache.
+	tmp := c.path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("writing cache: %w", err)
+	}
+	return os.Rename(tmp, c.path)
+}
+
+// Clean removes the cache file. It is not an error if there is none.
+func (c *Cache) Clean() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = make(map[string]fingerprint)
+	if err := os.Remove(c.path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("removing cache: %w", err)
+	}
+	return nil
+}
+
+// staleness reports whether a cacheable recipe needs to run given its
+// current fingerprint, and a short human-readable reason either way.
+func (c *Cache) staleness(dir string, r *justfile.Recipe, fp fingerprint) (bool, string) {
+	old, ok := c.get(r.Name)
+	if !ok {
+		return true, "no previous run recorded"
+	}
+	if changed := fp.changed(old); len(changed) > 0 {
+		return true, strings.Join(changed, ", ") + " changed"
+	}
+	if !outputsExist(dir, r) {
+		return true, "outputs missing"
+	}
+	return false, "up to date"
+}
+
+// computeFingerprint fingerprints recipe r as it would run with the given
+// make variable assignments for its parameters. values holds the evaluated
+// variables, so that a backtick variable counts by its output rather than
+// its command.
+func computeFingerprint(dir string, jf *justfile.Justfile, values map[string]string, r *justfile.Recipe, vars []string) (fingerprint, error) {
+	var fp fingerprint
+
+	files, err := glob.Expand(dir, r.Inputs)
+	if err != nil {
+		return fp, fmt.Errorf("expanding inputs for '%s': %w", r.Name, err)
+	}
+	h := sha256.New()
+	for _, f := range files {
+		fh, err := hashFile(filepath.Join(dir, filepath.FromSlash(f)))
+		if err != nil {
+			return fp, err
+		}
+		fmt.Fprintf(h, "%s %s\n", fh, f)
+	}
+	fp.Inputs = hex.EncodeToString(h.Sum(nil))
+
+	scope, err := bindParams(jf, r, values, vars, dir)
+	if err != nil {
+		return fp, err
+	}
+	lookup := func(name string) string { return scope[name] }
+
+	var body strings.Builder
+	for _, line := range r.Lines {
+		body.WriteString(justfile.ExpandInterpolations(line, lookup))
+		body.WriteString("\n")
+	}
+	// Exported variables reach every command's environment, so they are
+	// part of the body's meaning even when not interpolated.
+	for _, v := range jf.Variables {
+		if v.Export {
+			fmt.Fprintf(&body, "export %s=%s\n", v.Name, values[v.Name])
+		}
+	}
+	fp.Body = hashString(body.String())
+
+	var paramStr strings.Builder
+	for _, p := range r.Params {
+		fmt.Fprintf(&paramStr, "%s=%s\n", p.Name, lookup(p.Name))
+	}
+	fp.Params = hashString(paramStr.String())
+
+	var env strings.Builder
+	for _, name := range referencedEnv(jf, r) {
+		val, ok := os.LookupEnv(name)
+		fmt.Fprintf(&env, "%s=%t:%s\n", name, ok, val)
+	}
+	fp.Env = hashString(env.String())
+
+	return fp, nil
+}
+
+// referencedEnv returns the sorted names of environment variables that a
+// recipe's body or the justfile's variables refer to.
+func referencedEnv(jf *justfile.Justfile, r *justfile.Recipe) []string {
+	seen := make(map[string]bool)
+	collect := func(s string) {
+		for _, m := range envRefRe.FindAllStringSubmatch(s, -1) {
+			seen[m[1]] = true
+		}
+		for _, m := range envFuncRe.FindAllStringSubmatch(s, -1) {
+			seen[m[1]] = true
+		}
+	}
+	for _, line := range r.Lines {
+		collect(line)
+	}
+	for _, v := range jf.Variables {
+		collect(v.Value)
+	}
+
+	// Names defined in the justfile are make variables, not environment.
+	for _, v := range jf.Variables {
+		delete(seen, v.Name)
+	}
+	names := make([]string, 0, len(seen))
+	for n := range seen {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func hashString(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+func hashFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", fmt.Errorf("hashing %s: %w", path, err)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// outputsExist reports whether every declared output of r exists under dir.
+func outputsExist(dir string, r *justfile.Recipe) bool {
+	for _, o := range r.Outputs {
+		if _, err := os.Stat(filepath.Join(dir, o)); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// WriteCacheStatus prints whether each recipe reachable from target would
+// run, and why. The target itself is evaluated with vars; dependencies take
+// no arguments. With an empty target every recipe is reported.
+func WriteCacheStatus(w io.Writer, jf *justfile.Justfile, dir string, cache *Cache, target string, vars []string, force bool) error {
+	var names []string
+	if target == "" {
+		for _, r := range jf.Recipes {
+			if !r.IsListDefault() {
+				names = append(names, r.Name)
+			}
+		}
+	} else {
+		names = executionOrder(jf, target)
+	}
+
+	var values map[string]string
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, name := range names {
+		r := jf.FindRecipe(name)
+		if r == nil {
+			return fmt.Errorf("unknown recipe: %s", name)
+		}
+
+		status := "would run: no inputs declared"
+		if r.Cacheable() {
+			var rv []string
+			if name == target {
+				rv = vars
+			}
+			if values == nil {
+				var err error
+				if values, _, err = evaluateVariables(jf, dir); err != nil {
+					return err
+				}
+			}
+			fp, err := computeFingerprint(dir, jf, values, r, rv)
+			if err != nil {
+				return err
+			}
+			stale, reason := cache.staleness(dir, r, fp)
+			switch {
+			case force:
+				status = "would run: --force"
+			case stale:
+				status = "would run: " + reason
+			default:
+				status = "skip: " + reason
+			}
+		}
+		fmt.Fprintf(tw, "%s\t%s\n", name, status)
+	}
+	return tw.Flush()
+}
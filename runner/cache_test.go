@@ -0,0 +1,121 @@
+package runner
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sammcj/jmake/justfile"
+)
+
+func TestFingerprintStaleness(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "main.go")
+	if err := os.WriteFile(src, []byte("package main\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	input := `[inputs("*.go")]
+lint level="1":
+	golint -level={{level}}
+`
+	jf, err := justfile.Parse(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	r := &jf.Recipes[0]
+
+	cache, err := LoadCache(dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	fp, err := computeFingerprint(dir, jf, nil, r, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	stale, reason := cache.staleness(dir, r, fp)
+	assertEqual(t, "stale before first run", stale, true)
+	assertEqual(t, "reason", reason, "no previous run recorded")
+
+	if err := cache.set(r.Name, fp); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Reload from disk to check persistence.
+	cache, err = LoadCache(dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	stale, _ = cache.staleness(dir, r, fp)
+	assertEqual(t, "stale after recording", stale, false)
+
+	fp2, _ := computeFingerprint(dir, jf, nil, r, []string{"level=2"})
+	_, reason = cache.staleness(dir, r, fp2)
+	assertEqual(t, "param change", reason, "recipe body, parameters changed")
+
+	if err := os.WriteFile(src, []byte("package main // changed\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	fp3, _ := computeFingerprint(dir, jf, nil, r, nil)
+	_, reason = cache.staleness(dir, r, fp3)
+	assertEqual(t, "input change", reason, "inputs changed")
+
+	if err := cache.Clean(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, CacheDir, cacheFile)); !os.IsNotExist(err) {
+		t.Error("cache file should have been removed")
+	}
+}
+
+func TestFingerprintBacktickOutput(t *testing.T) {
+	dir := t.TempDir()
+	version := filepath.Join(dir, "VERSION")
+	if err := os.WriteFile(version, []byte("1.0\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "main.go"), []byte("package main\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	input := "version := `cat VERSION`\n" + `export TAG := ` + "`cat VERSION`" + `
+
+[inputs("*.go")]
+build:
+	echo {{version}}
+`
+	jf, err := justfile.Parse(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	r := jf.FindRecipe("build")
+	fingerprintOf := func() fingerprint {
+		t.Helper()
+		values, _, err := evaluateVariables(jf, dir)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		fp, err := computeFingerprint(dir, jf, values, r, nil)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return fp
+	}
+
+	cache, err := LoadCache(dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := cache.set(r.Name, fingerprintOf()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// The command text is unchanged; only its output differs.
+	if err := os.WriteFile(version, []byte("1.1\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	_, reason := cache.staleness(dir, r, fingerprintOf())
+	assertEqual(t, "backtick output change", reason, "recipe body changed")
+}
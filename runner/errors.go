@@ -0,0 +1,146 @@
+package runner
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"sync"
+)
+
+var (
+	// make's report of a failed recipe line, e.g.
+	//   make: *** [/tmp/jmake-123.mk:12: build] Error 1
+	// Signals are reported the same way with "Interrupt" or "Terminated".
+	makeFailureRe = regexp.MustCompile(`^make(?:\[\d+\])?: \*\*\* \[(.+):(\d+): [^\]]+\] (.*)$`)
+
+	// make's report of a failure in a "-" prefixed line it carried on past, e.g.
+	//   make: [/tmp/jmake-123.mk:12: build] Error 1 (ignored)
+	makeIgnoredRe = regexp.MustCompile(`^make(?:\[\d+\])?: \[.+:\d+: [^\]]+\] Error \d+ \(ignored\)$`)
+
+	makeErrorCodeRe = regexp.MustCompile(`^Error (\d+)$`)
+)
+
+// ExitCoder is implemented by errors that carry a specific exit status.
+type ExitCoder interface {
+	ExitCode() int
+}
+
+// RecipeError reports a recipe command that exited unsuccessfully.
+type RecipeError struct {
+	Recipe string
+	Line   int // justfile line of the failing command, 0 if unknown
+	Code   int // exit status of the failing command
+}
+
+func (e *RecipeError) Error() string {
+	if e.Line > 0 {
+		return fmt.Sprintf("Recipe '%s' failed on line %d with exit code %d", e.Recipe, e.Line, e.Code)
+	}
+	return fmt.Sprintf("Recipe '%s' failed with exit code %d", e.Recipe, e.Code)
+}
+
+// ExitCode returns the failing command's exit status, so jmake exits with it.
+func (e *RecipeError) ExitCode() int {
+	return e.Code
+}
+
+// ReportedError is a failure that the recipe's process has already
+// reported on its stderr. jmake exits with its status and adds nothing.
+type ReportedError struct {
+	code int
+}
+
+func (e *ReportedError) Error() string {
+	return fmt.Sprintf("exit status %d", e.code)
+}
+
+func (e *ReportedError) ExitCode() int {
+	return e.code
+}
+
+// makeErrorFilter passes make's stderr through to w, removing make's own
+// failure messages, which refer to the temporary Makefile rather than the
+// justfile. The last failure seen is recorded so it can be reported in
+// justfile terms instead. Other lines are passed through translate, if set,
+// so that remaining references to the Makefile can be rewritten.
+type makeErrorFilter struct {
+	w         io.Writer
+	translate func(string) string
+
+	mu       sync.Mutex
+	buf      []byte
+	failLine int // Makefile line of the failing command
+	failCode int // its exit status; 0 if make gave none (e.g. a signal)
+	failed   bool
+}
+
+func (f *makeErrorFilter) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.buf = append(f.buf, p...)
+	for {
+		i := bytes.IndexByte(f.buf, '\n')
+		if i < 0 {
+			break
+		}
+		line := f.buf[:i+1]
+		if !f.swallow(bytes.TrimRight(line, "\n")) {
+			if _, err := f.w.Write(f.translated(line)); err != nil {
+				return 0, err
+			}
+		}
+		f.buf = f.buf[i+1:]
+	}
+	return len(p), nil
+}
+
+func (f *makeErrorFilter) translated(line []byte) []byte {
+	if f.translate == nil {
+		return line
+	}
+	return []byte(f.translate(string(line)))
+}
+
+// swallow records and reports whether line is one of make's own messages.
+func (f *makeErrorFilter) swallow(line []byte) bool {
+	if makeIgnoredRe.Match(line) {
+		return true
+	}
+	m := makeFailureRe.FindSubmatch(line)
+	if m == nil {
+		return false
+	}
+	f.failed = true
+	f.failLine, _ = strconv.Atoi(string(m[2]))
+	f.failCode = 0
+	if c := makeErrorCodeRe.FindSubmatch(m[3]); c != nil {
+		f.failCode, _ = strconv.Atoi(string(c[1]))
+	}
+	return true
+}
+
+// Flush writes out any trailing partial line.
+func (f *makeErrorFilter) Flush() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if len(f.buf) == 0 {
+		return nil
+	}
+	var err error
+	if !f.swallow(f.buf) {
+		_, err = f.w.Write(f.translated(f.buf))
+	}
+	f.buf = nil
+	return err
+}
+
+// failure returns the Makefile line and exit status of the failed command,
+// if make reported one.
+func (f *makeErrorFilter) failure() (line, code int, ok bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.failLine, f.failCode, f.failed
+}
@@ -0,0 +1,34 @@
+package runner
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMakeErrorFilter(t *testing.T) {
+	var out strings.Builder
+	f := &makeErrorFilter{w: &out}
+
+	_, _ = f.Write([]byte("compiling\nmake: [/tmp/jmake-1.mk:9: dep] Error 1 (ignored)\n"))
+	_, _ = f.Write([]byte("make: *** [/tmp/jmake-1.mk:12: bu"))
+	_, _ = f.Write([]byte("ild] Error 3\nleftover"))
+	if err := f.Flush(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	assertEqual(t, "passed through", out.String(), "compiling\nleftover")
+
+	line, code, ok := f.failure()
+	assertEqual(t, "failed", ok, true)
+	assertEqual(t, "makefile line", line, 12)
+	assertEqual(t, "exit code", code, 3)
+}
+
+func TestRecipeErrorMessage(t *testing.T) {
+	err := &RecipeError{Recipe: "build", Line: 14, Code: 1}
+	assertEqual(t, "message", err.Error(), "Recipe 'build' failed on line 14 with exit code 1")
+	assertEqual(t, "exit code", err.ExitCode(), 1)
+
+	err = &RecipeError{Recipe: "build", Code: 2}
+	assertEqual(t, "message without line", err.Error(), "Recipe 'build' failed with exit code 2")
+}
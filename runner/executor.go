@@ -0,0 +1,349 @@
+// Package runner runs justfile recipes: it schedules a recipe and its
+// dependencies, and hands each one to a Backend to run.
+package runner
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sammcj/jmake/justfile"
+	"github.com/sammcj/jmake/makegen"
+)
+
+// Executor runs a recipe and its dependencies, handing each recipe to the
+// backend separately. Scheduling lives in jmake rather than in the backend
+// so that each recipe's process can be given its own output sink, and so
+// that concurrency, caching and timings work the same for every backend.
+//
+// The exported fields may be set after NewExecutor and before Run.
+type Executor struct {
+	DryRun       bool      // print what would run instead of running it
+	Cache        *Cache    // nil disables up-to-date checks
+	Force        bool      // run cacheable recipes even when up to date
+	Timings      *Timings  // nil disables timing collection
+	JustfilePath string    // for diagnostics translated from generated output
+	Stdin        io.Reader // recipes' standard input; os.Stdin by default
+
+	// ProcessGroups runs each recipe in its own process group so that
+	// signals reach every process the recipe started, not just the first.
+	ProcessGroups bool
+
+	jf      *justfile.Justfile
+	backend Backend
+	file    string             // the backend's generated output
+	lines   *makegen.SourceMap // relates lines of file to the justfile
+	dir     string
+	jobs    int
+	output  *Output
+
+	sem  chan struct{}
+	mu   sync.Mutex
+	runs map[string]*recipeRun
+
+	// Running processes and the first signal received, guarded by mu.
+	procs   map[*os.Process]bool
+	stopSig os.Signal
+
+	// Variable values, evaluated at most once per run.
+	evalOnce sync.Once
+	vars     map[string]string
+	env      []string // exported variables as NAME=value
+	evalErr  error
+}
+
+// recipeRun tracks a single execution of a recipe so that dependencies
+// shared by several recipes run only once.
+type recipeRun struct {
+	done chan struct{}
+	err  error
+}
+
+// NewExecutor returns an Executor that runs recipes from jf in dir with the
+// backend, up to jobs at a time, writing their output to output.
+func NewExecutor(jf *justfile.Justfile, backend Backend, dir string, jobs int, output *Output) *Executor {
+	if jobs < 1 {
+		jobs = 1
+	}
+	return &Executor{
+		jf:      jf,
+		backend: backend,
+		dir:     dir,
+		jobs:    jobs,
+		output:  output,
+		Stdin:   os.Stdin,
+		sem:     make(chan struct{}, jobs),
+		runs:    make(map[string]*recipeRun),
+		procs:   make(map[*os.Process]bool),
+	}
+}
+
+// Load makes the backend's generated output available to the recipes it
+// runs. Backends with an extension read it from a temporary file, which
+// Close removes.
+func (e *Executor) Load(gen Generated) error {
+	e.lines = gen.Lines
+	ext := e.backend.Ext()
+	if ext == "" {
+		return nil
+	}
+	f, err := createTempFile("jmake-*" + ext)
+	if err != nil {
+		return fmt.Errorf("creating temp file: %w", err)
+	}
+	e.file = f.Name()
+	if _, err := f.WriteString(gen.Content); err != nil {
+		f.Close()
+		return fmt.Errorf("writing temp file: %w", err)
+	}
+	return f.Close()
+}
+
+// Close removes the temporary file written by Load, if any.
+func (e *Executor) Close() {
+	if e.file != "" {
+		removeTempFile(e.file)
+		e.file = ""
+	}
+}
+
+// Run executes the named recipe after its dependencies. args are the
+// recipe's arguments; dependencies take none. Cancelling ctx interrupts
+// running recipes as if jmake received SIGINT.
+func (e *Executor) Run(ctx context.Context, name string, args []string) error {
+	if err := checkCycles(e.jf, name); err != nil {
+		return err
+	}
+	stop := context.AfterFunc(ctx, func() { e.interrupt(os.Interrupt) })
+	defer stop()
+
+	err := e.runOnce(ctx, name, args)
+	if sig := e.stopped(); sig != nil {
+		return &SignalError{sig: sig}
+	}
+	return err
+}
+
+// runOnce runs name unless it has already been started, in which case it
+// waits for that run to finish and returns its result.
+func (e *Executor) runOnce(ctx context.Context, name string, args []string) error {
+	e.mu.Lock()
+	if r, ok := e.runs[name]; ok {
+		e.mu.Unlock()
+		<-r.done
+		return r.err
+	}
+	r := &recipeRun{done: make(chan struct{})}
+	e.runs[name] = r
+	e.mu.Unlock()
+
+	r.err = e.runRecipe(ctx, name, args)
+	close(r.done)
+	return r.err
+}
+
+func (e *Executor) runRecipe(ctx context.Context, name string, args []string) error {
+	recipe := e.jf.FindRecipe(name)
+	if recipe == nil {
+		return fmt.Errorf("unknown recipe: %s", name)
+	}
+	vars, err := recipe.BindArgs(args)
+	if err != nil {
+		return err
+	}
+
+	if err := e.runDeps(ctx, recipe); err != nil {
+		return err
+	}
+
+	job := &Job{
+		Recipe: recipe,
+		Args:   args,
+		Vars:   vars,
+		Done:   transitiveDeps(e.jf, name),
+		DryRun: e.DryRun,
+		File:   e.file,
+		Lines:  e.lines,
+		Stdout: e.output.stdout,
+		Stderr: e.output.stderr,
+		e:      e,
+	}
+
+	// Recipes with declared inputs are skipped when their fingerprint matches
+	// the last successful run.
+	var fp *fingerprint
+	if recipe.Cacheable() && e.Cache != nil {
+		values, _, err := e.variables()
+		if err != nil {
+			return err
+		}
+		cur, err := computeFingerprint(e.dir, e.jf, values, recipe, vars)
+		if err != nil {
+			return err
+		}
+		if stale, _ := e.Cache.staleness(e.dir, recipe, cur); !stale && !e.Force {
+			fmt.Fprintf(e.output.stderr, "jmake: '%s' is up to date\n", name)
+			if e.Timings != nil {
+				e.Timings.skipped(name)
+			}
+			return nil
+		}
+		fp = &cur
+		job.Rebuild = recipe.Incremental()
+	}
+
+	if e.DryRun {
+		return e.backend.Run(ctx, job)
+	}
+
+	select {
+	case e.sem <- struct{}{}:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	defer func() { <-e.sem }()
+
+	out := e.output.sink(name)
+	defer out.Close()
+	job.Stdout, job.Stderr = out.Stdout, out.Stderr
+
+	start := time.Now()
+	err = e.backend.Run(ctx, job)
+	if e.Timings != nil {
+		e.Timings.record(name, start, err)
+	}
+	if err != nil {
+		return err
+	}
+	if fp != nil {
+		return e.Cache.set(name, *fp)
+	}
+	return nil
+}
+
+// variables returns the value of every variable and the exported ones as
+// NAME=value, running backtick commands the first time it is called.
+func (e *Executor) variables() (map[string]string, []string, error) {
+	e.evalOnce.Do(func() {
+		e.vars, e.env, e.evalErr = evaluateVariables(e.jf, e.dir)
+	})
+	return e.vars, e.env, e.evalErr
+}
+
+// runDeps runs a recipe's dependencies, concurrently when more than one job
+// is allowed. Dry runs are always sequential so the printed order is stable.
+func (e *Executor) runDeps(ctx context.Context, r *justfile.Recipe) error {
+	deps := make([]string, 0, len(r.Dependencies))
+	for _, d := range r.Dependencies {
+		deps = append(deps, e.jf.ResolveAlias(d))
+	}
+
+	if e.jobs == 1 || e.DryRun || len(deps) < 2 {
+		for _, d := range deps {
+			if err := e.runOnce(ctx, d, nil); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	errs := make([]error, len(deps))
+	var wg sync.WaitGroup
+	for i, d := range deps {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = e.runOnce(ctx, d, nil)
+		}()
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// transitiveDeps returns every recipe name reachable through the
+// dependencies of name, with aliases resolved, in first-visited order.
+func transitiveDeps(jf *justfile.Justfile, name string) []string {
+	var out []string
+	seen := map[string]bool{name: true}
+	var visit func(string)
+	visit = func(n string) {
+		r := jf.FindRecipe(n)
+		if r == nil {
+			return
+		}
+		for _, d := range r.Dependencies {
+			d = jf.ResolveAlias(d)
+			if seen[d] {
+				continue
+			}
+			seen[d] = true
+			out = append(out, d)
+			visit(d)
+		}
+	}
+	visit(name)
+	return out
+}
+
+// executionOrder returns name and its transitive dependencies in the order
+// a sequential run would execute them: each recipe after its dependencies.
+func executionOrder(jf *justfile.Justfile, name string) []string {
+	var out []string
+	seen := make(map[string]bool)
+	var visit func(string)
+	visit = func(n string) {
+		if seen[n] {
+			return
+		}
+		seen[n] = true
+		if r := jf.FindRecipe(n); r != nil {
+			for _, d := range r.Dependencies {
+				visit(jf.ResolveAlias(d))
+			}
+		}
+		out = append(out, n)
+	}
+	visit(name)
+	return out
+}
+
+// checkCycles returns an error if the dependency graph reachable from name
+// contains a cycle or refers to an unknown recipe.
+func checkCycles(jf *justfile.Justfile, name string) error {
+	const (
+		visiting = 1
+		visited  = 2
+	)
+	state := make(map[string]int)
+	var visit func(n string, path []string) error
+	visit = func(n string, path []string) error {
+		switch state[n] {
+		case visiting:
+			return fmt.Errorf("dependency cycle: %s", strings.Join(append(path, n), " -> "))
+		case visited:
+			return nil
+		}
+		r := jf.FindRecipe(n)
+		if r == nil {
+			return fmt.Errorf("unknown recipe: %s", n)
+		}
+		state[n] = visiting
+		for _, d := range r.Dependencies {
+			if err := visit(jf.ResolveAlias(d), append(path, n)); err != nil {
+				return err
+			}
+		}
+		state[n] = visited
+		return nil
+	}
+	return visit(name, nil)
+}
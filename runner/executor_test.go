@@ -0,0 +1,41 @@
+package runner
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/sammcj/jmake/justfile"
+)
+
+func TestTransitiveDepsAndCycles(t *testing.T) {
+	input := `alias t := test
+
+all: build t
+	echo all
+
+build: gen
+	go build
+
+test: gen
+	go test
+
+gen:
+	go generate
+`
+	jf, err := justfile.Parse(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	got := strings.Join(transitiveDeps(jf, "all"), " ")
+	assertEqual(t, "deps", got, "build gen test")
+
+	if err := checkCycles(jf, "all"); err != nil {
+		t.Errorf("unexpected cycle error: %v", err)
+	}
+
+	jf.Recipes = append(jf.Recipes, justfile.Recipe{Name: "loop", Dependencies: []string{"loop"}})
+	if err := checkCycles(jf, "loop"); err == nil {
+		t.Error("expected cycle error")
+	}
+}
@@ -0,0 +1,184 @@
+package runner
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/sammcj/jmake/justfile"
+	"github.com/sammcj/jmake/makegen"
+)
+
+// nativeShell runs recipe lines and backtick expressions for the native
+// backend. It matches the SHELL of generated Makefiles.
+var nativeShell = []string{"/bin/bash", "-c"}
+
+func init() {
+	RegisterBackend(func() Backend { return &nativeBackend{} })
+}
+
+// nativeBackend runs recipe lines itself, each in a new shell as just does,
+// without generating anything or needing make.
+type nativeBackend struct{}
+
+func (*nativeBackend) Name() string { return "native" }
+func (*nativeBackend) Ext() string  { return "" }
+
+func (*nativeBackend) Generate(jf *justfile.Justfile, opts makegen.Options) Generated {
+	return Generated{}
+}
+
+// Run echoes each line of the recipe to stderr, unless it starts with @,
+// then runs it. A failing line stops the recipe unless it starts with -.
+func (*nativeBackend) Run(ctx context.Context, j *Job) error {
+	vars, env, err := j.e.variables()
+	if err != nil {
+		return err
+	}
+	scope, err := bindParams(j.e.jf, j.Recipe, vars, j.Vars, j.e.dir)
+	if err != nil {
+		return err
+	}
+
+	for i, line := range j.Recipe.Lines {
+		quiet, ignoreErr := j.Recipe.Silent, false
+		for len(line) > 0 && (line[0] == '@' || line[0] == '-') {
+			if line[0] == '@' {
+				quiet = !j.Recipe.Silent
+			} else {
+				ignoreErr = true
+			}
+			line = line[1:]
+		}
+		line, err := interpolate(line, scope)
+		if err != nil {
+			return fmt.Errorf("recipe '%s' line %d: %w", j.Recipe.Name, j.Recipe.BodyLine(i), err)
+		}
+
+		if j.DryRun {
+			fmt.Fprintln(j.Stdout, line)
+			continue
+		}
+		if !quiet {
+			fmt.Fprintln(j.Stderr, line)
+		}
+
+		cmd := exec.Command(nativeShell[0], append(nativeShell[1:], line)...)
+		cmd.Env = append(os.Environ(), env...)
+		start := time.Now()
+		err = j.Exec(cmd)
+		if t := j.e.Timings; t != nil {
+			t.recordLine(j.Recipe.Name, j.Recipe.BodyLine(i), start, err)
+		}
+		var ee *exec.ExitError
+		switch {
+		case err == nil:
+		case errors.As(err, &ee) && ee.ExitCode() > 0:
+			if !ignoreErr {
+				return &RecipeError{Recipe: j.Recipe.Name, Line: j.Recipe.BodyLine(i), Code: ee.ExitCode()}
+			}
+		default:
+			return err
+		}
+	}
+	return nil
+}
+
+// evaluateVariables computes the value of every variable in jf, in order,
+// running backtick commands in dir with the variables exported so far in
+// their environment. It also returns the exported variables as NAME=value.
+func evaluateVariables(jf *justfile.Justfile, dir string) (map[string]string, []string, error) {
+	vars := make(map[string]string)
+	var env []string
+	for _, v := range jf.Variables {
+		value := v.Value
+		if v.Backtick {
+			var err error
+			if value, err = runBacktick(v.Value, dir, env); err != nil {
+				return nil, nil, fmt.Errorf("variable %s: backtick `%s` failed: %w", v.Name, v.Value, err)
+			}
+		}
+		vars[v.Name] = value
+		if v.Export {
+			env = append(env, v.Name+"="+value)
+		}
+	}
+	return vars, env, nil
+}
+
+// bindParams returns the values in scope for recipe r's body: the
+// justfile's variables, given as values, and each parameter with its
+// assignment in vars or else its default. A default naming a variable or
+// an earlier parameter takes its value; a backtick default runs in dir.
+func bindParams(jf *justfile.Justfile, r *justfile.Recipe, values map[string]string, vars []string, dir string) (map[string]string, error) {
+	args := make(map[string]string)
+	for _, v := range vars {
+		name, value, _ := strings.Cut(v, "=")
+		args[name] = value
+	}
+
+	scope := make(map[string]string)
+	maps.Copy(scope, values)
+	for _, p := range r.Params {
+		value, ok := args[p.Name]
+		switch {
+		case ok:
+		case p.DefaultKind == justfile.DefaultVariable:
+			value = scope[p.Default]
+		case p.DefaultKind == justfile.DefaultBacktick:
+			var env []string
+			for _, v := range jf.Variables {
+				if v.Export {
+					env = append(env, v.Name+"="+values[v.Name])
+				}
+			}
+			var err error
+			if value, err = runBacktick(p.Default, dir, env); err != nil {
+				return nil, fmt.Errorf("recipe '%s' parameter %s: backtick `%s` failed: %w", r.Name, p.Name, p.Default, err)
+			}
+		default:
+			value = p.Default
+		}
+		scope[p.Name] = value
+	}
+	return scope, nil
+}
+
+// runBacktick runs a backtick command in dir with env added to the
+// environment, and returns its output without trailing newlines.
+func runBacktick(command, dir string, env []string) (string, error) {
+	var stdout bytes.Buffer
+	cmd := exec.Command(nativeShell[0], append(nativeShell[1:], command)...)
+	cmd.Env = append(os.Environ(), env...)
+	cmd.Dir = dir
+	cmd.Stdout = &stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return "", err
+	}
+	return strings.TrimRight(stdout.String(), "\n"), nil
+}
+
+// interpolate replaces each {{name}} in line with its value in scope.
+func interpolate(line string, scope map[string]string) (string, error) {
+	var err error
+	out := justfile.ExpandInterpolations(line, func(name string) string {
+		value, ok := scope[name]
+		switch {
+		case ok:
+			return value
+		case !justfile.IsName(name):
+			err = fmt.Errorf("expression {{%s}} is not supported", name)
+		case err == nil:
+			err = fmt.Errorf("variable '%s' not defined", name)
+		}
+		return "{{" + name + "}}"
+	})
+	return out, err
+}
@@ -0,0 +1,218 @@
+package runner
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+// OutputMode controls how output from concurrently running recipes is written.
+type OutputMode string
+
+const (
+	OutputInterleaved OutputMode = "interleaved" // write straight through
+	OutputPrefixed    OutputMode = "prefixed"    // tag each line with [recipe]
+	OutputGrouped     OutputMode = "grouped"     // buffer and flush per recipe
+)
+
+// ParseOutputMode validates an --output-mode value. An empty string selects interleaved.
+func ParseOutputMode(s string) (OutputMode, error) {
+	switch OutputMode(s) {
+	case "", OutputInterleaved:
+		return OutputInterleaved, nil
+	case OutputPrefixed, OutputGrouped:
+		return OutputMode(s), nil
+	}
+	return "", fmt.Errorf("invalid output mode %q (want interleaved, prefixed or grouped)", s)
+}
+
+// ANSI colours cycled through for recipe prefixes.
+var prefixColours = []string{"36", "33", "32", "35", "34", "31"}
+
+// Output hands out per-recipe writers that share the process's stdout
+// and stderr. All writes to the underlying streams are serialised by mu.
+type Output struct {
+	mode   OutputMode
+	stdout io.Writer
+	stderr io.Writer
+	colour bool
+
+	mu      sync.Mutex
+	colours map[string]string
+}
+
+// NewOutput returns an Output writing to stdout and stderr in the given
+// mode, with colour recipe prefixes if colour is set.
+func NewOutput(mode OutputMode, stdout, stderr io.Writer, colour bool) *Output {
+	return &Output{
+		mode:    mode,
+		stdout:  stdout,
+		stderr:  stderr,
+		colour:  colour,
+		colours: make(map[string]string),
+	}
+}
+
+// recipeOutput is the pair of writers a single recipe's process writes to.
+// Close must be called once the process has exited.
+type recipeOutput struct {
+	Stdout io.Writer
+	Stderr io.Writer
+	close  func()
+}
+
+// Close flushes any buffered output for the recipe.
+func (o *recipeOutput) Close() {
+	if o.close != nil {
+		o.close()
+	}
+}
+
+// sink returns the writers for the named recipe according to the output mode.
+func (m *Output) sink(name string) *recipeOutput {
+	switch m.mode {
+	case OutputPrefixed:
+		prefix := m.prefix(name)
+		stdout := &lineWriter{mu: &m.mu, w: m.stdout, prefix: prefix}
+		stderr := &lineWriter{mu: &m.mu, w: m.stderr, prefix: prefix}
+		return &recipeOutput{Stdout: stdout, Stderr: stderr, close: func() {
+			stdout.flush()
+			stderr.flush()
+		}}
+	case OutputGrouped:
+		g := &groupBuffer{}
+		return &recipeOutput{
+			Stdout: g.writer(false),
+			Stderr: g.writer(true),
+			close: func() {
+				m.mu.Lock()
+				defer m.mu.Unlock()
+				g.flush(m.stdout, m.stderr)
+			},
+		}
+	default:
+		return &recipeOutput{Stdout: m.stdout, Stderr: m.stderr}
+	}
+}
+
+// prefix returns the "[name] " tag for a recipe, coloured if enabled.
+// Each recipe keeps the same colour for the lifetime of the manager.
+func (m *Output) prefix(name string) string {
+	if !m.colour {
+		return "[" + name + "] "
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	c, ok := m.colours[name]
+	if !ok {
+		c = prefixColours[len(m.colours)%len(prefixColours)]
+		m.colours[name] = c
+	}
+	return "\x1b[" + c + "m[" + name + "]\x1b[0m "
+}
+
+// lineWriter writes complete lines to w with a prefix, holding back any
+// trailing partial line until it is completed or flushed.
+type lineWriter struct {
+	mu     *sync.Mutex
+	w      io.Writer
+	prefix string
+	buf    []byte
+}
+
+func (l *lineWriter) Write(p []byte) (int, error) {
+	l.buf = append(l.buf, p...)
+	for {
+		i := bytes.IndexByte(l.buf, '\n')
+		if i < 0 {
+			break
+		}
+		if err := l.emit(l.buf[:i+1]); err != nil {
+			return 0, err
+		}
+		l.buf = l.buf[i+1:]
+	}
+	return len(p), nil
+}
+
+func (l *lineWriter) emit(line []byte) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	_, err := io.WriteString(l.w, l.prefix+string(line))
+	return err
+}
+
+func (l *lineWriter) flush() {
+	if len(l.buf) > 0 {
+		_ = l.emit(append(l.buf, '\n'))
+		l.buf = nil
+	}
+}
+
+// groupBuffer records a recipe's output in order, remembering which stream
+// each chunk was written to so it can be replayed faithfully.
+type groupBuffer struct {
+	mu     sync.Mutex
+	chunks []groupChunk
+}
+
+type groupChunk struct {
+	stderr bool
+	data   []byte
+}
+
+type groupStream struct {
+	g      *groupBuffer
+	stderr bool
+}
+
+func (g *groupBuffer) writer(stderr bool) io.Writer {
+	return &groupStream{g: g, stderr: stderr}
+}
+
+func (s *groupStream) Write(p []byte) (int, error) {
+	s.g.mu.Lock()
+	defer s.g.mu.Unlock()
+	s.g.chunks = append(s.g.chunks, groupChunk{stderr: s.stderr, data: bytes.Clone(p)})
+	return len(p), nil
+}
+
+func (g *groupBuffer) flush(stdout, stderr io.Writer) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	for _, c := range g.chunks {
+		if c.stderr {
+			_, _ = stderr.Write(c.data)
+		} else {
+			_, _ = stdout.Write(c.data)
+		}
+	}
+	g.chunks = nil
+}
+
+// UseColour reports whether ANSI colour should be written to f.
+// The when argument is the --color value: "always", "never" or "auto".
+// In auto mode colour is used only for terminals and when NO_COLOR is unset.
+func UseColour(when string, f *os.File) bool {
+	switch when {
+	case "always":
+		return true
+	case "never":
+		return false
+	}
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	return IsTerminal(f)
+}
+
+// IsTerminal reports whether f is a character device such as a terminal.
+func IsTerminal(f *os.File) bool {
+	fi, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return fi.Mode()&os.ModeCharDevice != 0
+}
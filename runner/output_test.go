@@ -0,0 +1,51 @@
+package runner
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestOutputPrefixed(t *testing.T) {
+	var stdout, stderr strings.Builder
+	m := NewOutput(OutputPrefixed, &stdout, &stderr, false)
+
+	out := m.sink("build")
+	_, _ = out.Stdout.Write([]byte("one\ntw"))
+	_, _ = out.Stdout.Write([]byte("o\nthree"))
+	_, _ = out.Stderr.Write([]byte("oops\n"))
+	out.Close()
+
+	assertEqual(t, "stdout", stdout.String(), "[build] one\n[build] two\n[build] three\n")
+	assertEqual(t, "stderr", stderr.String(), "[build] oops\n")
+}
+
+func TestOutputGrouped(t *testing.T) {
+	var stdout, stderr strings.Builder
+	m := NewOutput(OutputGrouped, &stdout, &stderr, false)
+
+	a := m.sink("a")
+	b := m.sink("b")
+	_, _ = a.Stdout.Write([]byte("a1\n"))
+	_, _ = b.Stdout.Write([]byte("b1\n"))
+	_, _ = a.Stderr.Write([]byte("a-err\n"))
+	_, _ = a.Stdout.Write([]byte("a2\n"))
+
+	assertEqual(t, "nothing written before close", stdout.String(), "")
+
+	b.Close()
+	a.Close()
+
+	assertEqual(t, "stdout", stdout.String(), "b1\na1\na2\n")
+	assertEqual(t, "stderr", stderr.String(), "a-err\n")
+}
+
+func TestParseOutputMode(t *testing.T) {
+	for _, s := range []string{"", "interleaved", "prefixed", "grouped"} {
+		if _, err := ParseOutputMode(s); err != nil {
+			t.Errorf("ParseOutputMode(%q): unexpected error: %v", s, err)
+		}
+	}
+	if _, err := ParseOutputMode("tabular"); err == nil {
+		t.Error("expected error for unknown mode")
+	}
+}
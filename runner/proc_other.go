@@ -0,0 +1,21 @@
+//go:build !unix
+
+package runner
+
+import (
+	"os"
+	"os/exec"
+)
+
+// setProcessGroup is a no-op on platforms without POSIX process groups.
+func setProcessGroup(cmd *exec.Cmd) {}
+
+// signalGroup signals only p itself on platforms without process groups.
+// Interrupts cannot be delivered to other processes on Windows, so they
+// fall back to killing the process.
+func signalGroup(p *os.Process, sig os.Signal) error {
+	if err := p.Signal(sig); err != nil {
+		return p.Kill()
+	}
+	return nil
+}
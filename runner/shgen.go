@@ -0,0 +1,329 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/sammcj/jmake/justfile"
+	"github.com/sammcj/jmake/makegen"
+)
+
+func init() {
+	RegisterBackend(func() Backend { return shellBackend{} })
+}
+
+// shellBackend generates a POSIX sh script and runs each recipe through it.
+type shellBackend struct{}
+
+func (shellBackend) Name() string { return "sh" }
+func (shellBackend) Ext() string  { return ".sh" }
+
+func (shellBackend) Generate(jf *justfile.Justfile, opts makegen.Options) Generated {
+	var warnings []makegen.Warning
+	for _, r := range jf.Recipes {
+		if r.Cacheable() || r.Incremental() {
+			warnings = append(warnings, makegen.Warning{Line: r.Line,
+				Message: fmt.Sprintf("recipe '%s': [inputs] and [outputs] are ignored; the recipe always runs", r.Name)})
+		}
+		warnings = append(warnings, expressionWarnings(&r)...)
+	}
+	return Generated{Content: GenerateShell(jf, opts), Warnings: warnings}
+}
+
+// shellSource runs the script named by its first argument with the
+// remaining arguments, in the current directory: sourcing the script keeps
+// $0 from pointing its cd at the temporary directory.
+const shellSource = `f=$1; shift; . "$f"`
+
+// Run runs the script with the recipe's name and arguments. The run-once
+// guard of each dependency already run is set in the environment, so the
+// script skips them. The script reports failures itself.
+func (shellBackend) Run(ctx context.Context, j *Job) error {
+	args := append([]string{j.Recipe.Name}, j.Args...)
+	if j.DryRun {
+		fmt.Fprintf(j.Stdout, "sh %s %s\n", j.File, strings.Join(args, " "))
+		return nil
+	}
+
+	cmd := exec.Command("sh", append([]string{"-c", shellSource, "jmake", j.File}, args...)...)
+	cmd.Env = os.Environ()
+	for _, dep := range j.Done {
+		cmd.Env = append(cmd.Env, "__jmake_done_"+shellName(dep)+"=1")
+	}
+	err := j.Exec(cmd)
+	var ee *exec.ExitError
+	if errors.As(err, &ee) && ee.ExitCode() > 0 {
+		return &ReportedError{code: ee.ExitCode()}
+	}
+	return err
+}
+
+// GenerateShell produces a POSIX sh script from a parsed Justfile, for
+// machines where neither make nor jmake is available. Each recipe becomes a
+// function that runs its dependencies, binds its parameters from "$@" and
+// then runs its lines, each in its own subshell as just does. A recipe runs
+// at most once per invocation of the script. A case statement at the end
+// dispatches on the first argument. Like just, recipes run in the
+// directory containing the script.
+// If the default recipe calls `just --list` and opts has a help target,
+// running the script without arguments prints the recipe listing instead,
+// as the help target does in a generated Makefile. Other options don't
+// apply to scripts.
+func GenerateShell(jf *justfile.Justfile, opts makegen.Options) string {
+	listDefault := opts.HelpTarget != "" && jf.HasListDefault()
+
+	var b strings.Builder
+
+	b.WriteString("#!/bin/sh\n")
+	b.WriteString("# Generated by jmake - do not edit\n\n")
+	b.WriteString("cd \"$(dirname \"$0\")\" || exit 1\n\n")
+
+	// Variables.
+	for _, v := range jf.Variables {
+		name := shellName(v.Name)
+		if v.Backtick {
+			fmt.Fprintf(&b, "%s=$(%s)\n", name, v.Value)
+		} else {
+			fmt.Fprintf(&b, "%s=%s\n", name, shellQuote(v.Value))
+		}
+		if v.Export {
+			fmt.Fprintf(&b, "export %s\n", name)
+		}
+	}
+	if len(jf.Variables) > 0 {
+		b.WriteString("\n")
+	}
+
+	b.WriteString(`__jmake_usage() {
+	echo "Usage: $0 [recipe] [args...]"
+	echo
+	cat <<'__JMAKE_USAGE__'
+`)
+	b.WriteString(justfile.ListRecipes(jf, justfile.DefaultListOptions()))
+	b.WriteString("__JMAKE_USAGE__\n}\n\n")
+
+	b.WriteString(`__jmake_fail() {
+	echo "error: Recipe '$1' failed on line $2 with exit code $3" >&2
+	exit "$3"
+}
+
+__jmake_missing() {
+	echo "error: recipe '$1' requires argument '$2'" >&2
+	exit 1
+}
+
+`)
+
+	// Constant variables are substituted into recipe lines as text, as
+	// just and make do, rather than expanded as single shell words.
+	consts := make(map[string]string)
+	for _, v := range jf.Variables {
+		if !v.Backtick {
+			consts[v.Name] = v.Value
+		}
+	}
+
+	// Recipes.
+	for _, r := range jf.Recipes {
+		if listDefault && r.IsListDefault() {
+			continue
+		}
+		writeShellRecipe(&b, &r, consts)
+	}
+
+	writeShellDispatch(&b, jf, listDefault)
+	return b.String()
+}
+
+// writeShellRecipe writes the function for one recipe. Missing arguments
+// are reported before any dependency runs, matching jmake.
+func writeShellRecipe(b *strings.Builder, r *justfile.Recipe, consts map[string]string) {
+	name := shellName(r.Name)
+
+	b.WriteString(justfile.FormatComment(r.Doc))
+	fmt.Fprintf(b, "recipe_%s() {\n", name)
+	fmt.Fprintf(b, "\t[ -z \"${__jmake_done_%s:-}\" ] || return 0\n", name)
+	fmt.Fprintf(b, "\t__jmake_done_%s=1\n", name)
+
+	for i, p := range r.Params {
+		if !p.HasDefault && p.Variadic != "*" {
+			fmt.Fprintf(b, "\t[ $# -ge %d ] || __jmake_missing %s %s\n", i+1, shellQuote(r.Name), shellQuote(p.Name))
+		}
+	}
+
+	for _, dep := range r.Dependencies {
+		fmt.Fprintf(b, "\trecipe_%s\n", shellName(dep))
+	}
+
+	for _, p := range r.Params {
+		param := shellName(p.Name)
+		switch {
+		case p.Variadic != "" && p.HasDefault:
+			fmt.Fprintf(b, "\t%s=%s; if [ $# -gt 0 ]; then %s=\"$*\"; fi\n", param, shellDefault(p), param)
+		case p.Variadic != "":
+			fmt.Fprintf(b, "\t%s=\"$*\"\n", param)
+		case !p.HasDefault:
+			fmt.Fprintf(b, "\t%s=$1; shift\n", param)
+		default:
+			fmt.Fprintf(b, "\t%s=%s; if [ $# -gt 0 ]; then %s=$1; shift; fi\n", param, shellDefault(p), param)
+		}
+	}
+
+	for i, line := range r.Lines {
+		writeShellLine(b, r, consts, i, line)
+	}
+	b.WriteString("}\n\n")
+}
+
+// writeShellLine writes one recipe line: it is echoed to stderr unless
+// quiet, then run in a subshell. A failure stops the script with just's
+// error message unless the line starts with "-". Lines that interpolate
+// parameters or backtick variables are run with eval, so that their values
+// are split and quoted as part of the line, as with just.
+func writeShellLine(b *strings.Builder, r *justfile.Recipe, consts map[string]string, i int, line string) {
+	quiet := r.Silent
+	ignoreErr := false
+	for len(line) > 0 && (line[0] == '@' || line[0] == '-') {
+		if line[0] == '@' {
+			quiet = !r.Silent
+		} else {
+			ignoreErr = true
+		}
+		line = line[1:]
+	}
+	if strings.TrimSpace(line) == "" {
+		return
+	}
+
+	frags := substituteConstants(r, consts, line)
+	word := shellWord(frags)
+	if !quiet {
+		fmt.Fprintf(b, "\tprintf '%%s\\n' %s >&2\n", word)
+	}
+	cmd := "eval " + word
+	if len(frags) == 1 && !frags[0].Interpolation {
+		cmd = frags[0].Text
+	}
+	if ignoreErr {
+		fmt.Fprintf(b, "\t( %s ) || true\n", cmd)
+	} else {
+		fmt.Fprintf(b, "\t( %s ) || __jmake_fail %s %d $?\n", cmd, shellQuote(r.Name), r.BodyLine(i))
+	}
+}
+
+// writeShellDispatch writes the case statement that runs the recipe named
+// by the script's first argument, or the default recipe if there is none.
+func writeShellDispatch(b *strings.Builder, jf *justfile.Justfile, listDefault bool) {
+	aliases := make(map[string][]string)
+	for _, a := range jf.Aliases {
+		aliases[a.Target] = append(aliases[a.Target], a.Name)
+	}
+
+	b.WriteString("case \"${1:-}\" in\n")
+	b.WriteString("-h | --help | -l | --list)\n\t__jmake_usage\n\t;;\n")
+	b.WriteString("\"\")\n")
+	switch {
+	case listDefault || len(jf.Recipes) == 0:
+		b.WriteString("\t__jmake_usage\n")
+	default:
+		fmt.Fprintf(b, "\trecipe_%s\n", shellName(jf.Recipes[0].Name))
+	}
+	b.WriteString("\t;;\n")
+
+	for _, r := range jf.Recipes {
+		if listDefault && r.IsListDefault() {
+			continue
+		}
+		names := append([]string{r.Name}, aliases[r.Name]...)
+		fmt.Fprintf(b, "%s)\n\tshift\n\trecipe_%s \"$@\"\n\t;;\n", strings.Join(names, " | "), shellName(r.Name))
+	}
+
+	b.WriteString("*)\n")
+	b.WriteString("\techo \"error: Justfile does not contain recipe '$1'\" >&2\n")
+	b.WriteString("\t__jmake_usage >&2\n")
+	b.WriteString("\texit 1\n")
+	b.WriteString("\t;;\n")
+	b.WriteString("esac\n")
+}
+
+// shellName maps a justfile identifier to a valid sh name. Identifiers may
+// contain '-', which sh names may not.
+func shellName(name string) string {
+	return strings.ReplaceAll(name, "-", "_")
+}
+
+// shellDefault returns a parameter's default as a sh word. A variable or
+// earlier parameter is expanded, and a backtick runs when the recipe does.
+func shellDefault(p justfile.Param) string {
+	switch p.DefaultKind {
+	case justfile.DefaultVariable:
+		return `"${` + shellName(p.Default) + `}"`
+	case justfile.DefaultBacktick:
+		return `"$(` + p.Default + `)"`
+	}
+	return shellQuote(p.Default)
+}
+
+// shellQuote single-quotes s for sh.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// substituteConstants splits line into fragments with each interpolation
+// of a constant variable replaced by its value as literal text. Parameters,
+// which shadow variables of the same name, and backtick variables remain
+// interpolations. Adjacent literal text is merged.
+func substituteConstants(r *justfile.Recipe, consts map[string]string, line string) []justfile.Fragment {
+	params := make(map[string]bool)
+	for _, p := range r.Params {
+		params[p.Name] = true
+	}
+
+	var frags []justfile.Fragment
+	for _, frag := range justfile.Fragments(line) {
+		if value, ok := consts[frag.Text]; ok && frag.Interpolation && !params[frag.Text] {
+			frag = justfile.Fragment{Text: value}
+		}
+		if n := len(frags); n > 0 && !frag.Interpolation && !frags[n-1].Interpolation {
+			frags[n-1].Text += frag.Text
+			continue
+		}
+		frags = append(frags, frag)
+	}
+	return frags
+}
+
+// shellWord returns a double-quoted sh word that expands to the line made
+// of frags as just would echo it: interpolations are replaced by their
+// values and the rest is printed literally.
+func shellWord(frags []justfile.Fragment) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, frag := range frags {
+		if frag.Interpolation {
+			b.WriteString("${" + shellName(frag.Text) + "}")
+		} else {
+			b.WriteString(escapeDoubleQuoted(frag.Text))
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
+// escapeDoubleQuoted escapes the characters that are special inside a
+// double-quoted sh word.
+func escapeDoubleQuoted(s string) string {
+	var b strings.Builder
+	for _, c := range s {
+		switch c {
+		case '"', '\\', '$', '`':
+			b.WriteByte('\\')
+		}
+		b.WriteRune(c)
+	}
+	return b.String()
+}
@@ -0,0 +1,151 @@
+package runner
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sammcj/jmake/justfile"
+	"github.com/sammcj/jmake/makegen"
+)
+
+func TestGenerateShell(t *testing.T) {
+	input := `name := "world"
+
+alias b := build
+
+# Build it
+build:
+	@echo build {{name}}
+
+deploy env tag="latest" *rest: build
+	@echo deploy {{env}} {{tag}} {{rest}}
+
+fail:
+	-false
+	exit 4
+`
+
+	jf, err := justfile.Parse(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	script := filepath.Join(t.TempDir(), "run.sh")
+	if err := os.WriteFile(script, []byte(GenerateShell(jf, makegen.DefaultOptions())), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	run := func(args ...string) (string, int) {
+		t.Helper()
+		out, err := exec.Command("sh", append([]string{script}, args...)...).CombinedOutput()
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return string(out), exitErr.ExitCode()
+		}
+		if err != nil {
+			t.Fatalf("running script: %v", err)
+		}
+		return string(out), 0
+	}
+
+	out, code := run()
+	assertEqual(t, "default output", out, "build world\n")
+	assertEqual(t, "default exit", code, 0)
+
+	out, _ = run("b")
+	assertEqual(t, "alias output", out, "build world\n")
+
+	out, _ = run("deploy", "prod", "v2", "a", "b")
+	assertEqual(t, "deploy output", out, "build world\ndeploy prod v2 a b\n")
+
+	out, _ = run("deploy", "prod")
+	assertEqual(t, "deploy with default", out, "build world\ndeploy prod latest\n")
+
+	out, code = run("deploy")
+	assertEqual(t, "missing arg", out, "error: recipe 'deploy' requires argument 'env'\n")
+	assertEqual(t, "missing arg exit", code, 1)
+
+	out, code = run("fail")
+	assertEqual(t, "fail output", out, "false\nexit 4\nerror: Recipe 'fail' failed on line 14 with exit code 4\n")
+	assertEqual(t, "fail exit", code, 4)
+
+	_, code = run("nope")
+	assertEqual(t, "unknown recipe exit", code, 1)
+}
+
+func TestGenerateShellRunOnce(t *testing.T) {
+	input := `a:
+	@echo a
+
+b: a
+	@echo b
+
+all: a b
+`
+
+	jf, err := justfile.Parse(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	out, err := exec.Command("sh", "-c", GenerateShell(jf, makegen.DefaultOptions()), "run.sh", "all").CombinedOutput()
+	if err != nil {
+		t.Fatalf("running script: %v\n%s", err, out)
+	}
+	assertEqual(t, "output", string(out), "a\nb\n")
+}
+
+func TestGenerateShellInterpolationSplitsLikeJust(t *testing.T) {
+	input := `flags := "-tags 'a b'"
+tag := ` + "`echo \"'c d'\"`" + `
+
+args *rest:
+	printf '[%s]' {{flags}} {{tag}} {{rest}}
+`
+
+	jf, err := justfile.Parse(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	script := GenerateShell(jf, makegen.DefaultOptions())
+	if !strings.Contains(script, `eval "printf '[%s]' -tags 'a b' ${tag} ${rest}"`) {
+		t.Errorf("constant not substituted into eval'd line:\n%s", script)
+	}
+	out, err := exec.Command("sh", "-c", script, "run.sh", "args", "e", "'f g'").CombinedOutput()
+	if err != nil {
+		t.Fatalf("running script: %v\n%s", err, out)
+	}
+	assertEqual(t, "output", string(out), "printf '[%s]' -tags 'a b' 'c d' e 'f g'\n[-tags][a b][c d][e][f g]")
+}
+
+func TestGenerateShellListDefault(t *testing.T) {
+	input := `default:
+	@just --list
+
+build:
+	@echo build
+`
+
+	jf, err := justfile.Parse(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	script := GenerateShell(jf, makegen.DefaultOptions())
+	out, err := exec.Command("sh", "-c", script, "run.sh").CombinedOutput()
+	if err != nil {
+		t.Fatalf("running script: %v\n%s", err, out)
+	}
+	if !strings.Contains(string(out), "Usage:") || !strings.Contains(string(out), "build") {
+		t.Errorf("expected the recipe listing, got:\n%s", out)
+	}
+
+	// Without a help target the default recipe is kept as it is.
+	opts := makegen.DefaultOptions()
+	opts.HelpTarget = ""
+	if script := GenerateShell(jf, opts); !strings.Contains(script, "recipe_default() {") {
+		t.Errorf("default recipe was replaced:\n%s", script)
+	}
+}
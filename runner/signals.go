@@ -0,0 +1,162 @@
+package runner
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// killDelay is how long recipes are given to exit after a signal has been
+// forwarded to them before they are killed.
+const killDelay = 5 * time.Second
+
+// forwardedSignals are intercepted by jmake while recipes run and passed on
+// to them, instead of terminating jmake and orphaning its children.
+var forwardedSignals = []os.Signal{os.Interrupt, syscall.SIGTERM, syscall.SIGHUP}
+
+// SignalError reports that a run was stopped by a signal. It carries the
+// conventional 128+N exit status.
+type SignalError struct {
+	sig os.Signal
+}
+
+func (e *SignalError) Error() string {
+	return fmt.Sprintf("interrupted by %s", e.sig)
+}
+
+// ExitCode returns 128 plus the signal number, as shells do.
+func (e *SignalError) ExitCode() int {
+	if s, ok := e.sig.(syscall.Signal); ok {
+		return 128 + int(s)
+	}
+	return 1
+}
+
+// ForwardSignals delivers forwardedSignals received by jmake to the
+// executor's running recipes until the returned stop function is called.
+func ForwardSignals(e *Executor) (stop func()) {
+	ch := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	signal.Notify(ch, forwardedSignals...)
+	go func() {
+		for {
+			select {
+			case sig := <-ch:
+				e.interrupt(sig)
+			case <-done:
+				return
+			}
+		}
+	}()
+	return func() {
+		signal.Stop(ch)
+		close(done)
+	}
+}
+
+// tempFiles tracks temporary files jmake has created so that every exit
+// path can remove them, including ones that bypass deferred calls.
+var tempFiles struct {
+	mu    sync.Mutex
+	paths map[string]bool
+}
+
+// createTempFile creates a temporary file like os.CreateTemp and registers
+// it for removal by RemoveTempFiles.
+func createTempFile(pattern string) (*os.File, error) {
+	f, err := os.CreateTemp("", pattern)
+	if err != nil {
+		return nil, err
+	}
+	tempFiles.mu.Lock()
+	defer tempFiles.mu.Unlock()
+	if tempFiles.paths == nil {
+		tempFiles.paths = make(map[string]bool)
+	}
+	tempFiles.paths[f.Name()] = true
+	return f, nil
+}
+
+// removeTempFile removes a file created by createTempFile.
+func removeTempFile(path string) {
+	tempFiles.mu.Lock()
+	defer tempFiles.mu.Unlock()
+	delete(tempFiles.paths, path)
+	_ = os.Remove(path)
+}
+
+// RemoveTempFiles removes every temporary file that is still registered.
+func RemoveTempFiles() {
+	tempFiles.mu.Lock()
+	defer tempFiles.mu.Unlock()
+	for p := range tempFiles.paths {
+		_ = os.Remove(p)
+	}
+	tempFiles.paths = nil
+}
+
+// start starts cmd and tracks it so that signals can be forwarded to it.
+// No new processes are started once the Executor has been interrupted.
+func (e *Executor) start(cmd *exec.Cmd) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.stopSig != nil {
+		return &SignalError{sig: e.stopSig}
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	e.procs[cmd.Process] = true
+	return nil
+}
+
+// finished stops tracking a process once it has been waited for.
+func (e *Executor) finished(p *os.Process) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	delete(e.procs, p)
+}
+
+// stopped returns the first signal the Executor was interrupted with, or nil.
+func (e *Executor) stopped() os.Signal {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.stopSig
+}
+
+// interrupt forwards sig to every running recipe and stops new ones from
+// starting. Processes still running killDelay after the first signal are
+// killed; a second signal kills them immediately.
+func (e *Executor) interrupt(sig os.Signal) {
+	e.mu.Lock()
+	first := e.stopSig == nil
+	if first {
+		e.stopSig = sig
+	}
+	e.mu.Unlock()
+
+	if !first {
+		e.signalAll(os.Kill)
+		return
+	}
+	e.signalAll(sig)
+	time.AfterFunc(killDelay, func() { e.signalAll(os.Kill) })
+}
+
+// signalAll sends sig to every tracked process, or to its whole process
+// group when recipes run in groups of their own.
+func (e *Executor) signalAll(sig os.Signal) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for p := range e.procs {
+		if e.ProcessGroups {
+			_ = signalGroup(p, sig)
+		} else {
+			_ = p.Signal(sig)
+		}
+	}
+}
@@ -0,0 +1,34 @@
+package runner
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"syscall"
+	"testing"
+)
+
+func TestSignalErrorExitCode(t *testing.T) {
+	err := fmt.Errorf("running: %w", &SignalError{sig: syscall.SIGTERM})
+
+	var ec ExitCoder
+	if !errors.As(err, &ec) {
+		t.Fatal("SignalError should provide an exit code")
+	}
+	assertEqual(t, "SIGTERM exit code", ec.ExitCode(), 143)
+	assertEqual(t, "SIGINT exit code", (&SignalError{sig: os.Interrupt}).ExitCode(), 130)
+}
+
+func TestRemoveTempFiles(t *testing.T) {
+	f, err := createTempFile("jmake-test-*.mk")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	f.Close()
+
+	RemoveTempFiles()
+
+	if _, err := os.Stat(f.Name()); !os.IsNotExist(err) {
+		t.Errorf("temp file %s should have been removed", f.Name())
+	}
+}
@@ -0,0 +1,293 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/sammcj/jmake/justfile"
+	"github.com/sammcj/jmake/makegen"
+)
+
+func init() {
+	RegisterBackend(func() Backend { return taskfileBackend{} })
+}
+
+// taskfileBackend generates a Taskfile.yml for go-task (schema version 3).
+//
+// Recipes become tasks, dependencies become deps, doc comments become desc
+// and aliases become task aliases. Parameters are passed as Task variables
+// (task deploy env=prod): required ones are listed under requires, and
+// defaults are applied with the default template function. Exported
+// variables go under env, the rest under vars. [inputs] and [outputs] map
+// to sources and generates, and [confirm] to prompt.
+type taskfileBackend struct{}
+
+func (taskfileBackend) Name() string { return "taskfile" }
+func (taskfileBackend) Ext() string  { return ".yml" }
+
+// Run is not supported: Task would rerun dependencies jmake has already
+// run, and has no way to be told otherwise.
+func (taskfileBackend) Run(ctx context.Context, j *Job) error {
+	return fmt.Errorf("the taskfile backend cannot run recipes; use it with --dump")
+}
+
+func (taskfileBackend) Generate(jf *justfile.Justfile, opts makegen.Options) Generated {
+	var (
+		b        strings.Builder
+		warnings []makegen.Warning
+	)
+
+	b.WriteString("# Generated by jmake - do not edit\n")
+	b.WriteString("version: '3'\n")
+
+	writeTaskVars(&b, "vars", jf.Variables, false)
+	writeTaskVars(&b, "env", jf.Variables, true)
+
+	aliases := make(map[string][]string)
+	for _, a := range jf.Aliases {
+		aliases[a.Target] = append(aliases[a.Target], a.Name)
+	}
+
+	b.WriteString("\ntasks:\n")
+	for _, r := range jf.Recipes {
+		if r.IsListDefault() {
+			b.WriteString("  default:\n")
+			b.WriteString("    cmds:\n")
+			b.WriteString("      - task --list-all\n")
+			continue
+		}
+		warnings = append(warnings, writeTask(&b, &r, aliases[r.Name])...)
+	}
+
+	return Generated{Content: b.String(), Warnings: warnings}
+}
+
+// writeTaskVars writes the variables that are (or are not) exported as a
+// top-level mapping with the given key. Backtick values become dynamic
+// variables evaluated by the shell.
+func writeTaskVars(b *strings.Builder, key string, vars []justfile.Variable, exported bool) {
+	first := true
+	for _, v := range vars {
+		if v.Export != exported {
+			continue
+		}
+		if first {
+			fmt.Fprintf(b, "\n%s:\n", key)
+			first = false
+		}
+		if v.Backtick {
+			fmt.Fprintf(b, "  %s:\n    sh: %s\n", v.Name, yamlValue(v.Value, 4))
+		} else {
+			fmt.Fprintf(b, "  %s: %s\n", v.Name, yamlValue(v.Value, 2))
+		}
+	}
+}
+
+// writeTask writes one recipe as a task and returns warnings for anything
+// that does not carry over.
+func writeTask(b *strings.Builder, r *justfile.Recipe, aliases []string) []makegen.Warning {
+	var warnings []makegen.Warning
+	warn := func(format string, args ...any) {
+		msg := fmt.Sprintf("recipe '%s': ", r.Name) + fmt.Sprintf(format, args...)
+		warnings = append(warnings, makegen.Warning{Line: r.Line, Message: msg})
+	}
+
+	fmt.Fprintf(b, "  %s:\n", r.Name)
+	if r.Doc != "" {
+		fmt.Fprintf(b, "    desc: %s\n", yamlValue(r.Summary(), 4))
+	}
+	if r.Doc != r.Summary() {
+		// Task shows the summary, in full, with task --summary.
+		b.WriteString("    summary: |-\n")
+		for line := range strings.SplitSeq(r.Doc, "\n") {
+			if line == "" {
+				b.WriteString("\n")
+				continue
+			}
+			fmt.Fprintf(b, "      %s\n", line)
+		}
+	}
+	if len(aliases) > 0 {
+		fmt.Fprintf(b, "    aliases: %s\n", yamlList(aliases))
+	}
+	if len(r.Dependencies) > 0 {
+		fmt.Fprintf(b, "    deps: %s\n", yamlList(r.Dependencies))
+		if len(r.Dependencies) > 1 {
+			warn("Task runs dependencies concurrently rather than in order")
+		}
+	}
+	if len(r.Inputs) > 0 {
+		fmt.Fprintf(b, "    sources: %s\n", yamlList(r.Inputs))
+	}
+	if len(r.Outputs) > 0 {
+		fmt.Fprintf(b, "    generates: %s\n", yamlList(r.Outputs))
+	}
+
+	for _, a := range r.Attributes {
+		switch a.Name {
+		case "inputs", "outputs", "doc":
+		case "confirm":
+			prompt := fmt.Sprintf("Run recipe `%s`?", r.Name)
+			if len(a.Args) > 0 {
+				prompt = a.Args[0]
+			}
+			fmt.Fprintf(b, "    prompt: %s\n", yamlValue(prompt, 4))
+		default:
+			warn("attribute [%s] has no Taskfile equivalent", a.Name)
+		}
+	}
+
+	var required []string
+	for _, p := range r.Params {
+		if !p.HasDefault && p.Variadic != "*" {
+			required = append(required, p.Name)
+		}
+	}
+	if len(required) > 0 {
+		fmt.Fprintf(b, "    requires:\n      vars: %s\n", yamlList(required))
+	}
+
+	first := true
+	for _, p := range r.Params {
+		if !p.HasDefault && p.Variadic != "*" {
+			continue
+		}
+		if first {
+			b.WriteString("    vars:\n")
+			first = false
+		}
+		fallback := fmt.Sprintf("%q", p.Default)
+		switch p.DefaultKind {
+		case justfile.DefaultVariable:
+			fallback = "(" + taskRef(p.Default) + ")"
+		case justfile.DefaultBacktick:
+			warn("parameter '%s': Task cannot run a backtick default; it defaults to empty", p.Name)
+			fallback = `""`
+		}
+		fmt.Fprintf(b, "      %s: %s\n", p.Name, yamlQuote(fmt.Sprintf("{{%s | default %s}}", taskRef(p.Name), fallback)))
+	}
+
+	// In a silent recipe, @ makes a line echo instead. Task cannot echo a
+	// command of a silent task, so a silent recipe becomes a silent task
+	// only when none of its lines do; otherwise each silent line says so.
+	type taskCmd struct {
+		line              string
+		silent, ignoreErr bool
+	}
+	cmds := make([]taskCmd, len(r.Lines))
+	taskSilent := r.Silent
+	for i, line := range r.Lines {
+		c := taskCmd{silent: r.Silent}
+		for len(line) > 0 && (line[0] == '@' || line[0] == '-') {
+			if line[0] == '@' {
+				c.silent = !r.Silent
+			} else {
+				c.ignoreErr = true
+			}
+			line = line[1:]
+		}
+		c.line = taskTemplate(line)
+		cmds[i] = c
+		taskSilent = taskSilent && c.silent
+	}
+	if taskSilent {
+		b.WriteString("    silent: true\n")
+	}
+
+	if len(cmds) > 0 {
+		b.WriteString("    cmds:\n")
+	}
+	for _, c := range cmds {
+		silent := c.silent && !taskSilent
+		if !silent && !c.ignoreErr {
+			fmt.Fprintf(b, "      - %s\n", yamlQuote(c.line))
+			continue
+		}
+		fmt.Fprintf(b, "      - cmd: %s\n", yamlQuote(c.line))
+		if silent {
+			b.WriteString("        silent: true\n")
+		}
+		if c.ignoreErr {
+			b.WriteString("        ignore_error: true\n")
+		}
+	}
+
+	return append(warnings, expressionWarnings(r)...)
+}
+
+// taskTemplate converts a recipe line to a Task command template, in which
+// interpolations are variable references and a literal "{{" is escaped.
+func taskTemplate(line string) string {
+	var b strings.Builder
+	for _, frag := range justfile.Fragments(line) {
+		if frag.Interpolation {
+			b.WriteString("{{" + taskRef(frag.Text) + "}}")
+		} else {
+			b.WriteString(strings.ReplaceAll(frag.Text, "{{", `{{"{{"}}`))
+		}
+	}
+	return b.String()
+}
+
+// taskRef returns the Go template reference to a Task variable. Names
+// containing '-' cannot be written as .name and are looked up with index.
+func taskRef(name string) string {
+	if strings.Contains(name, "-") {
+		return fmt.Sprintf("index . %q", name)
+	}
+	return "." + name
+}
+
+// yamlQuote returns s as a single-quoted YAML scalar.
+func yamlQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+// yamlValue returns s as the YAML value of a mapping key indented by
+// indent spaces. Values with line breaks become literal block scalars,
+// since YAML folds the line breaks of single-quoted scalars into spaces.
+// A block scalar keeping several trailing line breaks would also take in
+// the blank lines after it, so such values are written double-quoted,
+// with escapes, instead.
+func yamlValue(s string, indent int) string {
+	body := strings.TrimRight(s, "\n")
+	trailing := len(s) - len(body)
+	switch {
+	case !strings.Contains(s, "\n"):
+		return yamlQuote(s)
+	case trailing > 1:
+		return strconv.Quote(s)
+	}
+
+	// The chomping indicator keeps the trailing line break if s has one,
+	// and an indentation indicator is needed when s starts with a space.
+	header := "|"
+	if strings.HasPrefix(body, " ") {
+		header += "2"
+	}
+	if trailing == 0 {
+		header += "-"
+	}
+
+	var b strings.Builder
+	b.WriteString(header)
+	pad := strings.Repeat(" ", indent+2)
+	for line := range strings.SplitSeq(body, "\n") {
+		b.WriteString("\n")
+		if line != "" {
+			b.WriteString(pad + line)
+		}
+	}
+	return b.String()
+}
+
+// yamlList returns items as a YAML flow sequence of quoted scalars.
+func yamlList(items []string) string {
+	quoted := make([]string, len(items))
+	for i, s := range items {
+		quoted[i] = yamlQuote(s)
+	}
+	return "[" + strings.Join(quoted, ", ") + "]"
+}
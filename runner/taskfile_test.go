@@ -0,0 +1,218 @@
+package runner
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/sammcj/jmake/justfile"
+	"github.com/sammcj/jmake/makegen"
+)
+
+func TestTaskfileBackend(t *testing.T) {
+	input := `version := "1.0"
+export GREETING := "it's"
+
+alias d := deploy
+
+# Deploy the app
+#
+# Builds first.
+deploy env tag="latest": build
+	@echo deploying {{tag}} to {{env}} {{version}}
+
+build:
+	-go build
+`
+
+	jf, err := justfile.Parse(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	gen := taskfileBackend{}.Generate(jf, makegen.DefaultOptions())
+	output, warnings := gen.Content, gen.Warnings
+
+	want := `# Generated by jmake - do not edit
+version: '3'
+
+vars:
+  version: '1.0'
+
+env:
+  GREETING: 'it''s'
+
+tasks:
+  deploy:
+    desc: 'Deploy the app'
+    summary: |-
+      Deploy the app
+
+      Builds first.
+    aliases: ['d']
+    deps: ['build']
+    requires:
+      vars: ['env']
+    vars:
+      tag: '{{.tag | default "latest"}}'
+    cmds:
+      - cmd: 'echo deploying {{.tag}} to {{.env}} {{.version}}'
+        silent: true
+  build:
+    cmds:
+      - cmd: 'go build'
+        ignore_error: true
+`
+	assertEqual(t, "taskfile", output, want)
+	assertEqual(t, "warnings", len(warnings), 0)
+}
+
+func TestTaskfileBackendWarnings(t *testing.T) {
+	input := `[private]
+a:
+	echo {{ os() }}
+
+b: a c
+	echo b
+
+c:
+	echo c
+
+d os=target tag=` + "`git describe`" + `:
+	echo {{os}} {{tag}}
+
+target := "linux"
+`
+
+	jf, err := justfile.Parse(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	gen := taskfileBackend{}.Generate(jf, makegen.DefaultOptions())
+	warnings := gen.Warnings
+	if !strings.Contains(gen.Content, "os: '{{.os | default (.target)}}'") {
+		t.Errorf("variable default not referenced:\n%s", gen.Content)
+	}
+
+	want := []makegen.Warning{
+		{Line: 2, Message: "recipe 'a': attribute [private] has no Taskfile equivalent"},
+		{Line: 3, Message: "recipe 'a': expression {{os()}} is not supported"},
+		{Line: 5, Message: "recipe 'b': Task runs dependencies concurrently rather than in order"},
+		{Line: 11, Message: "recipe 'd': parameter 'tag': Task cannot run a backtick default; it defaults to empty"},
+	}
+	if len(warnings) != len(want) {
+		t.Fatalf("got %d warnings, want %d: %v", len(warnings), len(want), warnings)
+	}
+	for i := range want {
+		assertEqual(t, "warning", warnings[i], want[i])
+	}
+}
+
+func TestTaskfileSilentRecipes(t *testing.T) {
+	input := `@quiet:
+	echo a
+	-echo b
+
+@mixed:
+	echo a
+	@echo b
+`
+
+	jf, err := justfile.Parse(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	output := taskfileBackend{}.Generate(jf, makegen.DefaultOptions()).Content
+
+	want := `  quiet:
+    silent: true
+    cmds:
+      - 'echo a'
+      - cmd: 'echo b'
+        ignore_error: true
+  mixed:
+    cmds:
+      - cmd: 'echo a'
+        silent: true
+      - 'echo b'
+`
+	if !strings.HasSuffix(output, want) {
+		t.Errorf("got:\n%s\nwant suffix:\n%s", output, want)
+	}
+}
+
+func TestTaskfileMultiLineValues(t *testing.T) {
+	for _, value := range []string{
+		"one line",
+		"it's",
+		"two\nlines",
+		"trailing\nbreak\n",
+		"several\n\nbreaks\n\n",
+		"  indented\nfirst line",
+		"\tcommand\n    with indent",
+	} {
+		jf := &justfile.Justfile{Variables: []justfile.Variable{{Name: "v", Value: value}}}
+		output := taskfileBackend{}.Generate(jf, makegen.DefaultOptions()).Content
+		assertEqual(t, fmt.Sprintf("round trip of %q", value), yamlMappingValue(t, output, "v"), value)
+	}
+}
+
+// yamlMappingValue decodes the value of key in YAML written by the
+// taskfile backend: a quoted scalar or a literal block scalar.
+func yamlMappingValue(t *testing.T, doc, key string) string {
+	t.Helper()
+	lines := strings.Split(doc, "\n")
+	for i, line := range lines {
+		trimmed := strings.TrimLeft(line, " ")
+		rest, ok := strings.CutPrefix(trimmed, key+": ")
+		if !ok {
+			continue
+		}
+		indent := len(line) - len(trimmed)
+		if strings.HasPrefix(rest, "'") {
+			return strings.ReplaceAll(rest[1:len(rest)-1], "''", "'")
+		}
+		if strings.HasPrefix(rest, `"`) {
+			value, err := strconv.Unquote(rest)
+			if err != nil {
+				t.Fatalf("%s: %v", rest, err)
+			}
+			return value
+		}
+
+		// A literal block scalar: |, an optional indentation indicator,
+		// and an optional chomping indicator.
+		header := rest[1:]
+		content := -1
+		if len(header) > 0 && header[0] >= '1' && header[0] <= '9' {
+			content = indent + int(header[0]-'0')
+			header = header[1:]
+		}
+		var body []string
+		for _, l := range lines[i+1:] {
+			if strings.TrimSpace(l) != "" && len(l)-len(strings.TrimLeft(l, " ")) <= indent {
+				break
+			}
+			if content < 0 && strings.TrimSpace(l) != "" {
+				content = len(l) - len(strings.TrimLeft(l, " "))
+			}
+			body = append(body, l)
+		}
+		for j, l := range body {
+			if len(l) >= content {
+				body[j] = l[content:]
+			} else {
+				body[j] = ""
+			}
+		}
+		value := strings.TrimRight(strings.Join(body, "\n"), "\n")
+		if header == "-" {
+			return value
+		}
+		return value + "\n"
+	}
+	t.Fatalf("no %s in:\n%s", key, doc)
+	return ""
+}
@@ -0,0 +1,216 @@
+package runner
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"text/tabwriter"
+	"time"
+)
+
+// timingRecord is the outcome of one recipe in a run, or of one of its
+// body lines when the backend runs them itself.
+type timingRecord struct {
+	Name     string
+	Line     int // justfile line of a body line; 0 for the whole recipe
+	Start    time.Time
+	End      time.Time
+	ExitCode int  // 0 on success, -1 if the process could not be run or was killed
+	Skipped  bool // up to date, so not run at all
+}
+
+// Timings collects per-recipe timing for --timings and --trace.
+type Timings struct {
+	start time.Time
+
+	mu      sync.Mutex
+	records []timingRecord
+}
+
+// NewTimings starts collecting timings, relative to now.
+func NewTimings() *Timings {
+	return &Timings{start: time.Now()}
+}
+
+// record adds a finished recipe. err is the result of running it.
+func (t *Timings) record(name string, start time.Time, err error) {
+	t.add(timingRecord{Name: name, Start: start, End: time.Now(), ExitCode: exitCode(err)})
+}
+
+// recordLine adds a finished body line of recipe name, at justfile line
+// line. err is the result of running it.
+func (t *Timings) recordLine(name string, line int, start time.Time, err error) {
+	t.add(timingRecord{Name: name, Line: line, Start: start, End: time.Now(), ExitCode: exitCode(err)})
+}
+
+// skipped records a recipe that was not run because it was up to date.
+func (t *Timings) skipped(name string) {
+	now := time.Now()
+	t.add(timingRecord{Name: name, Start: now, End: now, Skipped: true})
+}
+
+func (t *Timings) add(r timingRecord) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.records = append(t.records, r)
+}
+
+// sorted returns the records ordered by start time.
+func (t *Timings) sorted() []timingRecord {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	recs := make([]timingRecord, len(t.records))
+	copy(recs, t.records)
+	sort.SliceStable(recs, func(i, j int) bool { return recs[i].Start.Before(recs[j].Start) })
+	return recs
+}
+
+// WriteSummary prints a table of recipe durations and results, followed by
+// the total wall-clock time of the run. Body lines timed by the backend
+// are listed under their recipe.
+func (t *Timings) WriteSummary(w io.Writer) error {
+	recs := t.sorted()
+	lines := make(map[string][]timingRecord)
+	for _, r := range recs {
+		if r.Line > 0 {
+			lines[r.Name] = append(lines[r.Name], r)
+		}
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "Recipe\tDuration\tResult")
+	for _, r := range recs {
+		if r.Line > 0 {
+			continue
+		}
+		dur, result := r.outcome()
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", r.Name, dur, result)
+		for _, l := range lines[r.Name] {
+			dur, result := l.outcome()
+			fmt.Fprintf(tw, "  line %d\t%s\t%s\n", l.Line, dur, result)
+		}
+	}
+	fmt.Fprintf(tw, "Total\t%s\n", formatDuration(time.Since(t.start)))
+	return tw.Flush()
+}
+
+// outcome returns the duration and result of a record as shown in the
+// summary.
+func (r timingRecord) outcome() (dur, result string) {
+	switch {
+	case r.Skipped:
+		return "-", "up to date"
+	case r.ExitCode == 0:
+		return formatDuration(r.End.Sub(r.Start)), "ok"
+	case r.ExitCode < 0:
+		return formatDuration(r.End.Sub(r.Start)), "failed"
+	default:
+		return formatDuration(r.End.Sub(r.Start)), fmt.Sprintf("exit %d", r.ExitCode)
+	}
+}
+
+// traceEvent is a complete ("X") event in the Chrome trace-event format,
+// readable by chrome://tracing, Perfetto and speedscope.
+type traceEvent struct {
+	Name  string         `json:"name"`
+	Cat   string         `json:"cat"`
+	Phase string         `json:"ph"`
+	TS    int64          `json:"ts"`  // microseconds since the run started
+	Dur   int64          `json:"dur"` // microseconds
+	PID   int            `json:"pid"`
+	TID   int            `json:"tid"`
+	Args  map[string]any `json:"args,omitempty"`
+}
+
+// WriteTrace writes the run as Chrome trace-event JSON. Recipes that
+// overlap in time are placed on separate thread lanes so concurrency is
+// visible in the viewer. Body lines share their recipe's lane, where
+// viewers nest them under it.
+func (t *Timings) WriteTrace(w io.Writer) error {
+	var (
+		events   []traceEvent
+		laneEnds []time.Time
+		lanes    = make(map[string]int)
+	)
+	recs := t.sorted()
+	for _, r := range recs {
+		if r.Skipped || r.Line > 0 {
+			continue
+		}
+		lane := -1
+		for i, end := range laneEnds {
+			if !r.Start.Before(end) {
+				lane = i
+				break
+			}
+		}
+		if lane < 0 {
+			lane = len(laneEnds)
+			laneEnds = append(laneEnds, time.Time{})
+		}
+		laneEnds[lane] = r.End
+		lanes[r.Name] = lane
+
+		events = append(events, traceEvent{
+			Name:  r.Name,
+			Cat:   "recipe",
+			Phase: "X",
+			TS:    r.Start.Sub(t.start).Microseconds(),
+			Dur:   r.End.Sub(r.Start).Microseconds(),
+			PID:   1,
+			TID:   lane + 1,
+			Args:  map[string]any{"exit_code": r.ExitCode},
+		})
+	}
+	for _, r := range recs {
+		if r.Line == 0 {
+			continue
+		}
+		events = append(events, traceEvent{
+			Name:  fmt.Sprintf("%s:%d", r.Name, r.Line),
+			Cat:   "line",
+			Phase: "X",
+			TS:    r.Start.Sub(t.start).Microseconds(),
+			Dur:   r.End.Sub(r.Start).Microseconds(),
+			PID:   1,
+			TID:   lanes[r.Name] + 1,
+			Args:  map[string]any{"recipe": r.Name, "line": r.Line, "exit_code": r.ExitCode},
+		})
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(map[string]any{
+		"traceEvents":     events,
+		"displayTimeUnit": "ms",
+	})
+}
+
+// exitCode extracts an exit status from the error returned by running a
+// recipe: 0 for success, the status for a normal non-zero exit and -1
+// otherwise.
+func exitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+	var ec ExitCoder
+	if errors.As(err, &ec) {
+		return ec.ExitCode()
+	}
+	return -1
+}
+
+// formatDuration renders d with precision suited to its magnitude.
+func formatDuration(d time.Duration) string {
+	switch {
+	case d < time.Second:
+		return fmt.Sprintf("%dms", d.Milliseconds())
+	case d < time.Minute:
+		return fmt.Sprintf("%.2fs", d.Seconds())
+	default:
+		return d.Round(time.Second).String()
+	}
+}
@@ -0,0 +1,85 @@
+package runner
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTimingsSummaryAndTrace(t *testing.T) {
+	base := time.Now()
+	tm := &Timings{start: base}
+	tm.add(timingRecord{Name: "build", Start: base, End: base.Add(1500 * time.Millisecond)})
+	tm.add(timingRecord{Name: "lint", Start: base.Add(10 * time.Millisecond), End: base.Add(200 * time.Millisecond), ExitCode: 2})
+	tm.skipped("gen")
+
+	var summary strings.Builder
+	if err := tm.WriteSummary(&summary); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	out := summary.String()
+	for _, want := range []string{"build   1.50s     ok", "lint    190ms     exit 2", "gen     -         up to date", "Total"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("summary missing %q:\n%s", want, out)
+		}
+	}
+
+	var trace strings.Builder
+	if err := tm.WriteTrace(&trace); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var doc struct {
+		TraceEvents []traceEvent `json:"traceEvents"`
+	}
+	if err := json.Unmarshal([]byte(trace.String()), &doc); err != nil {
+		t.Fatalf("invalid trace JSON: %v", err)
+	}
+	// Skipped recipes have no span; overlapping recipes get separate lanes.
+	assertEqual(t, "events", len(doc.TraceEvents), 2)
+	assertEqual(t, "build lane", doc.TraceEvents[0].TID, 1)
+	assertEqual(t, "lint lane", doc.TraceEvents[1].TID, 2)
+	assertEqual(t, "build duration", doc.TraceEvents[0].Dur, int64(1500000))
+}
+
+func TestTimingsLines(t *testing.T) {
+	base := time.Now()
+	tm := &Timings{start: base}
+	tm.add(timingRecord{Name: "build", Start: base, End: base.Add(time.Second)})
+	tm.add(timingRecord{Name: "build", Line: 4, Start: base, End: base.Add(300 * time.Millisecond)})
+	tm.add(timingRecord{Name: "build", Line: 5, Start: base.Add(300 * time.Millisecond), End: base.Add(time.Second), ExitCode: 1})
+
+	var summary strings.Builder
+	if err := tm.WriteSummary(&summary); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	out := summary.String()
+	for _, want := range []string{"build     1.00s     ok\n  line 4  300ms     ok\n  line 5  700ms     exit 1\n"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("summary missing %q:\n%s", want, out)
+		}
+	}
+
+	var trace strings.Builder
+	if err := tm.WriteTrace(&trace); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var doc struct {
+		TraceEvents []traceEvent `json:"traceEvents"`
+	}
+	if err := json.Unmarshal([]byte(trace.String()), &doc); err != nil {
+		t.Fatalf("invalid trace JSON: %v", err)
+	}
+	// Lines share their recipe's lane rather than taking lanes of their own.
+	assertEqual(t, "events", len(doc.TraceEvents), 3)
+	assertEqual(t, "line name", doc.TraceEvents[2].Name, "build:5")
+	assertEqual(t, "line category", doc.TraceEvents[2].Cat, "line")
+	assertEqual(t, "line lane", doc.TraceEvents[2].TID, 1)
+	assertEqual(t, "line exit code", doc.TraceEvents[2].Args["exit_code"], any(float64(1)))
+}
+
+func TestExitCode(t *testing.T) {
+	assertEqual(t, "nil", exitCode(nil), 0)
+	assertEqual(t, "other error", exitCode(errors.New("boom")), -1)
+}
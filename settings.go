@@ -0,0 +1,28 @@
+package main
+
+import (
+	"path/filepath"
+
+	"github.com/sammcj/jmake/makegen"
+)
+
+// setting is a generator setting given on the command line, such as
+// --make-shell /bin/sh. Its name is the flag without the leading dashes.
+type setting struct {
+	name, value string
+}
+
+// generateOptions returns the generator options for a justfile in dir: the
+// defaults, then the config file if there is one, then flags.
+func generateOptions(dir string, flags []setting) (makegen.Options, error) {
+	opts := makegen.DefaultOptions()
+	if err := makegen.LoadConfig(filepath.Join(dir, makegen.ConfigFile), &opts); err != nil {
+		return opts, err
+	}
+	for _, s := range flags {
+		if err := opts.Set(s.name, s.value); err != nil {
+			return opts, err
+		}
+	}
+	return opts, opts.Validate()
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sammcj/jmake/makegen"
+)
+
+func TestGenerateOptionsConfig(t *testing.T) {
+	dir := t.TempDir()
+	config := `# house style
+make-shell = /bin/sh
+make-phony = per-target
+make-header = "Generated\nDo not edit"
+make-silent = true
+`
+	if err := os.WriteFile(filepath.Join(dir, makegen.ConfigFile), []byte(config), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Flags override the config file.
+	opts, err := generateOptions(dir, []setting{{"make-phony", "none"}, {"annotate", "true"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	assertEqual(t, "shell", opts.Shell, "/bin/sh")
+	assertEqual(t, "phony", opts.Phony, makegen.PhonyNone)
+	assertEqual(t, "header", opts.Header, "Generated\nDo not edit")
+	assertEqual(t, "silent", opts.Silent, true)
+	assertEqual(t, "source comments", opts.SourceComments, true)
+	assertEqual(t, "help target", opts.HelpTarget, "help")
+}
+
+func TestGenerateOptionsErrors(t *testing.T) {
+	tests := []struct {
+		config string
+		flags  []setting
+		want   string
+	}{
+		{config: "make-colour = red\n", want: `.jmakerc:1: unknown setting "make-colour"`},
+		{config: "\nmake-silent = sometimes\n", want: `.jmakerc:2: make-silent: invalid boolean "sometimes"`},
+		{config: "make-shell\n", want: ".jmakerc:1: expected name = value"},
+		{flags: []setting{{"make-phony", "some"}}, want: `invalid phony policy "some" (want all, per-target or none)`},
+		{flags: []setting{{"make-flavour", "bsd"}}, want: `invalid make flavour "bsd" (want gnu or posix)`},
+		{flags: []setting{{"make-help-target", "show help"}}, want: `invalid help target name "show help"`},
+		{
+			flags: []setting{{"make-flavor", "posix"}, {"make-oneshell", "true"}},
+			want:  "--make-oneshell requires the gnu make flavour",
+		},
+	}
+
+	for _, tt := range tests {
+		dir := t.TempDir()
+		if tt.config != "" {
+			if err := os.WriteFile(filepath.Join(dir, makegen.ConfigFile), []byte(tt.config), 0o644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		_, err := generateOptions(dir, tt.flags)
+		if err == nil {
+			t.Errorf("%q: expected error", tt.want)
+			continue
+		}
+		assertEqual(t, "error", strings.TrimPrefix(err.Error(), dir+string(filepath.Separator)), tt.want)
+	}
+}
+
+func TestParseArgsGenerateSettings(t *testing.T) {
+	opts := parseArgs([]string{"--make-shell", "/bin/sh", "--make-silent", "--annotate", "--dump"})
+
+	assertEqual(t, "dump", opts.dump, true)
+	assertEqual(t, "settings", len(opts.settings), 3)
+	assertEqual(t, "shell", opts.settings[0], setting{"make-shell", "/bin/sh"})
+	assertEqual(t, "silent", opts.settings[1], setting{"make-silent", "true"})
+	assertEqual(t, "annotate", opts.settings[2], setting{"annotate", "true"})
+}
@@ -0,0 +1,224 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/sammcj/jmake/internal/glob"
+	"github.com/sammcj/jmake/justfile"
+	"github.com/sammcj/jmake/runner"
+)
+
+const (
+	// watchPollInterval is how often the watched tree is scanned for changes.
+	watchPollInterval = 250 * time.Millisecond
+
+	// watchDebounce is how long the tree must stay unchanged after a change
+	// before the recipe is rerun, so that bursts of writes (a branch switch,
+	// an editor's save-and-format) trigger a single run.
+	watchDebounce = 300 * time.Millisecond
+)
+
+// fileState is what the watcher compares between scans.
+type fileState struct {
+	modTime int64
+	size    int64
+}
+
+// snapshot maps slash-separated paths, relative to the watched directory,
+// to their state at the time of a scan.
+type snapshot map[string]fileState
+
+// watcher polls a directory tree for changes. Polling keeps jmake free of
+// platform-specific notification APIs and behaves the same everywhere.
+type watcher struct {
+	dir      string
+	justfile string   // always watched, even when globs exclude it
+	globs    []string // if non-empty, only matching files are watched
+}
+
+// scan walks the watched directory, skipping .git, jmake's cache directory
+// and anything ignored by .gitignore.
+func (w *watcher) scan() (snapshot, error) {
+	ignore := loadGitignore(w.dir)
+	snap := make(snapshot)
+
+	err := filepath.WalkDir(w.dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			// Files can disappear mid-scan; that is itself a change we will
+			// see on the next scan.
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		rel, err := filepath.Rel(w.dir, p)
+		if err != nil {
+			return err
+		}
+		if rel == "." {
+			return nil
+		}
+		rel = filepath.ToSlash(rel)
+
+		if d.IsDir() {
+			if d.Name() == ".git" || rel == runner.CacheDir || ignore.ignored(rel, true) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if p != w.justfile && (ignore.ignored(rel, false) || !w.matches(rel)) {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		snap[rel] = fileState{modTime: info.ModTime().UnixNano(), size: info.Size()}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// The justfile may live outside the tree, as the global one does.
+	if rel, err := filepath.Rel(w.dir, w.justfile); err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		if info, err := os.Stat(w.justfile); err == nil {
+			snap[filepath.ToSlash(w.justfile)] = fileState{modTime: info.ModTime().UnixNano(), size: info.Size()}
+		}
+	}
+	return snap, nil
+}
+
+func (w *watcher) matches(rel string) bool {
+	if len(w.globs) == 0 {
+		return true
+	}
+	for _, g := range w.globs {
+		if glob.Match(g, rel) {
+			return true
+		}
+	}
+	return false
+}
+
+// waitForChange blocks until the tree differs from prev and has then been
+// quiet for watchDebounce, returning the settled snapshot.
+func (w *watcher) waitForChange(ctx context.Context, prev snapshot) (snapshot, error) {
+	ticker := time.NewTicker(watchPollInterval)
+	defer ticker.Stop()
+
+	var (
+		last      = prev
+		changedAt time.Time
+	)
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-ticker.C:
+		}
+
+		cur, err := w.scan()
+		if err != nil {
+			return nil, err
+		}
+		if !maps.Equal(cur, last) {
+			last = cur
+			changedAt = time.Now()
+			continue
+		}
+		if !changedAt.IsZero() && time.Since(changedAt) >= watchDebounce {
+			return cur, nil
+		}
+	}
+}
+
+// watch runs the requested recipe, then reruns it whenever files in the
+// directory it runs in change. A run still in progress when a change is
+// seen is cancelled first. The justfile is reloaded before every run, so
+// edits to it take effect immediately. Watching stops on SIGINT or SIGTERM.
+func watch(justfilePath string, opts options) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	w := &watcher{
+		dir:      opts.workDir,
+		justfile: justfilePath,
+		globs:    opts.watchGlobs,
+	}
+	snap, err := w.scan()
+	if err != nil {
+		return fmt.Errorf("watching: %w", err)
+	}
+
+	for {
+		runCtx, cancel := context.WithCancel(ctx)
+		var done chan error
+
+		jf, err := justfile.Load(justfilePath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "jmake: %s\n", err)
+		} else {
+			done = make(chan error, 1)
+			go func() { done <- execute(runCtx, jf, justfilePath, opts) }()
+		}
+
+		changes := make(chan snapshot, 1)
+		errs := make(chan error, 1)
+		go func() {
+			s, err := w.waitForChange(runCtx, snap)
+			if err != nil {
+				errs <- err
+				return
+			}
+			changes <- s
+		}()
+
+	wait:
+		for {
+			select {
+			case err := <-done:
+				done = nil
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "jmake: %s\n", err)
+				}
+				fmt.Fprintln(os.Stderr, "jmake: watching for changes...")
+			case s := <-changes:
+				snap = s
+				break wait
+			case err := <-errs:
+				if ctx.Err() == nil {
+					cancel()
+					if done != nil {
+						<-done
+					}
+					return fmt.Errorf("watching: %w", err)
+				}
+				break wait
+			}
+		}
+
+		cancel()
+		if done != nil {
+			<-done
+		}
+		if ctx.Err() != nil {
+			return nil
+		}
+		fmt.Fprintln(os.Stderr, "jmake: change detected, rerunning")
+	}
+}
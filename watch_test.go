@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestGitignore(t *testing.T) {
+	g := &gitignore{}
+	for _, line := range []string{"# comment", "*.log", "bin/", "/dist", "!keep.log", "docs/**/*.tmp"} {
+		g.add(line)
+	}
+
+	tests := []struct {
+		path  string
+		isDir bool
+		want  bool
+	}{
+		{"app.log", false, true},
+		{"sub/app.log", false, true},
+		{"keep.log", false, false},
+		{"bin", true, true},
+		{"bin", false, false},
+		{"dist", true, true},
+		{"sub/dist", true, false},
+		{"docs/a/b.tmp", false, true},
+		{"main.go", false, false},
+	}
+	for _, tt := range tests {
+		if got := g.ignored(tt.path, tt.isDir); got != tt.want {
+			t.Errorf("ignored(%q, %v) = %v, want %v", tt.path, tt.isDir, got, tt.want)
+		}
+	}
+}
+
+func TestWatcherDetectsChanges(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) {
+		t.Helper()
+		p := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(".gitignore", "out/\n")
+	write("justfile", "test:\n\techo hi\n")
+	write("main.go", "package main\n")
+	write("out/gen.go", "package out\n")
+	write("README.md", "readme\n")
+
+	w := &watcher{dir: dir, justfile: filepath.Join(dir, "justfile"), globs: []string{"**/*.go"}}
+	snap, err := w.scan()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, ok := snap["main.go"]; !ok {
+		t.Error("main.go should be watched")
+	}
+	if _, ok := snap["justfile"]; !ok {
+		t.Error("justfile should always be watched")
+	}
+	if _, ok := snap["out/gen.go"]; ok {
+		t.Error("ignored directory should not be watched")
+	}
+	if _, ok := snap["README.md"]; ok {
+		t.Error("file not matching globs should not be watched")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		write("lib.go", "package main\n")
+	}()
+	next, err := w.waitForChange(ctx, snap)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := next["lib.go"]; !ok {
+		t.Error("new file should appear in snapshot")
+	}
+}